@@ -0,0 +1,293 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/vanderhaka/treework/internal/backup"
+	"github.com/vanderhaka/treework/internal/config"
+	"github.com/vanderhaka/treework/internal/git"
+	"github.com/vanderhaka/treework/internal/journal"
+	"github.com/vanderhaka/treework/internal/sanitize"
+	"github.com/vanderhaka/treework/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var backupsCmd = &cobra.Command{
+	Use:   "backups",
+	Short: "List and restore backups of force-removed worktrees",
+	Long: `Before treework force-removes a worktree with unsaved work, or force-deletes
+an unmerged branch, it snapshots the work into refs under refs/treework/backup/
+in the repo. Use these commands to find and restore them.`,
+}
+
+var backupsListCmd = &cobra.Command{
+	Use:     "list [name]",
+	Aliases: []string{"ls"},
+	Short:   "List backups, optionally for one worktree",
+	Args:    cobra.MaximumNArgs(1),
+	Run:     runBackupsList,
+}
+
+var backupsShowCmd = &cobra.Command{
+	Use:               "show <backup>",
+	Short:             "Show what a backup contains",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeBackups,
+	Run:               runBackupsShow,
+}
+
+var backupsRestoreCmd = &cobra.Command{
+	Use:   "restore <backup> [new-name]",
+	Short: "Restore a backup into a new worktree",
+	Long: `Restore a backup into a new worktree. The original branch is recreated at
+its backed-up tip if it was deleted; otherwise a new branch named after the
+worktree is created. Uncommitted work comes back as unstaged changes.`,
+	Args:              cobra.RangeArgs(1, 2),
+	ValidArgsFunction: completeBackups,
+	Run:               runBackupsRestore,
+}
+
+var backupsPurgeCmd = &cobra.Command{
+	Use:   "purge [backup]",
+	Short: "Delete expired backups, or one backup",
+	Long: `With no argument, delete backups outside the retention settings
+(backup_retention_days and backup_keep). With a backup, delete just that one.`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeBackups,
+	Run:               runBackupsPurge,
+}
+
+var (
+	flagNoBackup    bool
+	flagPurgeAll    bool
+	flagPurgeDryRun bool
+)
+
+func init() {
+	backupsCmd.AddCommand(backupsListCmd)
+	backupsCmd.AddCommand(backupsShowCmd)
+	backupsCmd.AddCommand(backupsRestoreCmd)
+	backupsCmd.AddCommand(backupsPurgeCmd)
+
+	backupsPurgeCmd.Flags().BoolVar(&flagPurgeAll, "all", false, "delete every backup")
+	backupsPurgeCmd.Flags().BoolVar(&flagPurgeDryRun, "dry-run", false, "only list what would be deleted")
+	for _, c := range []*cobra.Command{rmCmd, clearCmd} {
+		c.Flags().BoolVar(&flagNoBackup, "no-backup", false, "don't back up unsaved work before removing")
+	}
+}
+
+// allBackups returns the backups of every repo in the base folders.
+func allBackups() []backup.Backup {
+	roots := requireRoots()
+	if len(roots) == 0 {
+		os.Exit(1)
+	}
+	var all []backup.Backup
+	for _, repo := range refreshRepos(roots) {
+		all = append(all, backup.List(repo)...)
+	}
+	return all
+}
+
+// findBackup resolves a backup argument, exiting if there is no match.
+func findBackup(query string) backup.Backup {
+	b, err := backup.Find(allBackups(), query)
+	if err != nil {
+		ui.Error(err.Error())
+		os.Exit(1)
+	}
+	return b
+}
+
+func runBackupsList(cmd *cobra.Command, args []string) {
+	fmt.Println()
+	all := allBackups()
+	if len(args) > 0 {
+		var matched []backup.Backup
+		for _, b := range all {
+			if b.Name == args[0] || strings.HasSuffix(b.Name, "-worktree-"+args[0]) {
+				matched = append(matched, b)
+			}
+		}
+		all = matched
+	}
+	if len(all) == 0 {
+		ui.Info("No backups found.")
+		fmt.Println()
+		return
+	}
+
+	for _, b := range all {
+		details := []string{ago(b.Created)}
+		if b.Branch != "" {
+			details = append(details, "branch "+b.Branch)
+		}
+		if !b.HasChanges() {
+			details = append(details, "branch only")
+		}
+		fmt.Printf("  %s  %s\n", b.ID, ui.MutedStyle.Render(strings.Join(details, " · ")))
+	}
+	fmt.Println()
+	ui.Muted("Restore one with: treework backups restore <backup> [new-name]")
+	fmt.Println()
+}
+
+func runBackupsShow(cmd *cobra.Command, args []string) {
+	fmt.Println()
+	b := findBackup(args[0])
+
+	ui.Info(ui.BoldStyle.Render(b.ID))
+	ui.Muted(fmt.Sprintf("Taken:    %s (%s)", b.Created.Local().Format("2006-01-02 15:04"), ago(b.Created)))
+	ui.Muted("Repo:     " + b.RepoDir)
+	if b.Path != "" {
+		ui.Muted("Worktree: " + b.Path)
+	}
+	if b.Branch != "" {
+		ui.Muted(fmt.Sprintf("Branch:   %s at %s", b.Branch, short(b.Tip)))
+		if !git.BranchExists(b.RepoDir, b.Branch) {
+			ui.Muted("          (branch has since been deleted)")
+		}
+	}
+	fmt.Println()
+
+	if !b.HasChanges() {
+		ui.Info("No uncommitted changes — this backup keeps the branch tip only.")
+	} else {
+		base := b.Tip
+		if base == "" {
+			base = b.Worktree + "^"
+		}
+		ui.Info("Uncommitted changes:")
+		fmt.Println(git.DiffStat(b.RepoDir, base, b.Worktree))
+	}
+	fmt.Println()
+}
+
+func runBackupsRestore(cmd *cobra.Command, args []string) {
+	fmt.Println()
+	b := findBackup(args[0])
+
+	name := strings.TrimPrefix(b.Name, b.Repo+"-worktree-")
+	if len(args) > 1 {
+		name = sanitize.Name(args[1])
+	}
+	wtPath := worktreePath(b.RepoDir, name)
+	if _, err := os.Stat(wtPath); err == nil {
+		ui.Error(fmt.Sprintf("'%s' already exists — pass a new name: treework backups restore %s <new-name>", wtPath, args[0]))
+		os.Exit(1)
+	}
+
+	branch, err := backup.Restore(b, wtPath, name)
+	if err != nil {
+		ui.Error(fmt.Sprintf("Restore failed: %v", err))
+		os.Exit(1)
+	}
+	ui.Success(fmt.Sprintf("Restored %s → %s (branch: %s)", b.ID, wtPath, branch))
+	record(journal.Entry{Op: journal.OpCreate, Repo: b.RepoDir, Path: wtPath, Branch: branch, SHA: git.RevParse(wtPath, "HEAD"), Backup: b.ID})
+	fmt.Println()
+}
+
+func runBackupsPurge(cmd *cobra.Command, args []string) {
+	fmt.Println()
+
+	var doomed []backup.Backup
+	switch {
+	case len(args) > 0:
+		doomed = []backup.Backup{findBackup(args[0])}
+	case flagPurgeAll:
+		doomed = allBackups()
+	default:
+		for _, repo := range refreshRepos(requireRoots()) {
+			days, keep := config.BackupRetentionFor(repo)
+			doomed = append(doomed, backup.Expired(repo, days, keep)...)
+		}
+	}
+
+	if len(doomed) == 0 {
+		ui.Info("No backups to delete.")
+		fmt.Println()
+		return
+	}
+	for _, b := range doomed {
+		ui.Muted(fmt.Sprintf("  • %s  (%s)", b.ID, ago(b.Created)))
+	}
+	fmt.Println()
+	if flagPurgeDryRun {
+		return
+	}
+
+	if flagPurgeAll || len(args) > 0 {
+		ok, err := ui.Confirm(fmt.Sprintf("Delete %d backup(s)? This cannot be undone", len(doomed)))
+		if err != nil {
+			handleAbort(err)
+		}
+		if !ok {
+			ui.Muted("Cancelled.")
+			fmt.Println()
+			return
+		}
+	}
+
+	deleted := 0
+	for _, b := range doomed {
+		if err := backup.Delete(b); err != nil {
+			ui.Warn(fmt.Sprintf("Failed to delete %s: %v", b.ID, err))
+			continue
+		}
+		deleted++
+	}
+	ui.Success(fmt.Sprintf("Deleted %d backup(s)", deleted))
+	fmt.Println()
+}
+
+// backupBeforeRemove snapshots a worktree (or just its branch, if the folder
+// is gone) and applies the repo's retention settings. Returns the backup ID,
+// or false if the backup failed, in which case nothing should be destroyed.
+func backupBeforeRemove(repoDir, wtPath, branch string) (string, bool) {
+	b, err := backup.Create(repoDir, wtPath, branch)
+	if err != nil {
+		ui.Error(fmt.Sprintf("Backup of %s failed: %v", filepath.Base(wtPath), err))
+		return "", false
+	}
+	ui.Muted(fmt.Sprintf("Backed up to %s — restore with: treework backups restore %s", b.ID, b.ID))
+
+	days, keep := config.BackupRetentionFor(repoDir)
+	for _, old := range backup.Expired(repoDir, days, keep) {
+		backup.Delete(old)
+	}
+	return b.ID, true
+}
+
+// ago formats a time as a rough age, e.g. "3 days ago".
+func ago(t time.Time) string {
+	d := time.Since(t)
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return plural(int(d.Minutes()), "minute") + " ago"
+	case d < 24*time.Hour:
+		return plural(int(d.Hours()), "hour") + " ago"
+	default:
+		return plural(int(d.Hours()/24), "day") + " ago"
+	}
+}
+
+func plural(n int, unit string) string {
+	if n == 1 {
+		return "1 " + unit
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
+
+// short abbreviates a commit SHA for display.
+func short(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
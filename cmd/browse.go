@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/vanderhaka/treework/internal/config"
+	"github.com/vanderhaka/treework/internal/editor"
+	"github.com/vanderhaka/treework/internal/env"
+	"github.com/vanderhaka/treework/internal/fileutil"
+	"github.com/vanderhaka/treework/internal/git"
+	"github.com/vanderhaka/treework/internal/parallel"
+	"github.com/vanderhaka/treework/internal/ui"
+)
+
+// previewCommits is how many commits the browser's preview pane lists.
+const previewCommits = 5
+
+// browseWorktrees shows dirs in the full-screen worktree browser and returns
+// the chosen worktree and what to do with it (one of the ui.Action values).
+// When the user asks to see every repo's worktrees the browser reopens with
+// all of roots'.
+func browseWorktrees(b ui.Browser, roots []string, scope string, dirs []string) (string, string, error) {
+	for {
+		// Each lookup runs git, so do them side by side; Ctrl+C just exits
+		items := make([]ui.WorktreeDisplay, len(dirs))
+		mains := make([]string, len(dirs))
+		parallel.ForEach(context.Background(), parallel.Limit(), len(dirs), func(i int) {
+			d := dirs[i]
+			repo := extractRepoName(filepath.Base(d))
+			if main := git.MainRepoDir(d); main != "" {
+				repo = filepath.Base(main)
+				mains[i] = main
+			}
+			locked, reason := git.LockStatus(d)
+			items[i] = ui.WorktreeDisplay{
+				Path:       d,
+				Branch:     git.CurrentBranch(d),
+				Repo:       repo,
+				Locked:     locked,
+				LockReason: reason,
+				Current:    inCurrentDir(d),
+			}
+		})
+
+		b.Scope = scope
+		b.Check = worktreeStateCheck(items, mains)
+		b.Preview = worktreePreview
+		b.Size = fileutil.DirSize
+		selected, action, err := ui.BrowseWorktrees(items, b)
+		if err != nil || selected != ui.AllValue {
+			return selected, action, err
+		}
+		scope, dirs = "", worktreeDirs(roots)
+	}
+}
+
+// worktreePreview gathers what the browser's preview pane shows.
+func worktreePreview(path string) ui.WorktreePreview {
+	status := git.CheckWorktreeStatus(path)
+	return ui.WorktreePreview{
+		Branch:   status.Branch,
+		Upstream: status.Upstream,
+		Ahead:    status.Ahead,
+		Behind:   status.Behind,
+		Status:   status.Files,
+		Commits:  git.RecentCommits(path, previewCommits),
+		EnvFiles: env.List(path),
+	}
+}
+
+// worktreeStateCheck returns the check the worktree browser runs on each worktree
+// in the background. Merged checks use the last fetched state, even with
+// fetch_before_merge_check on, so the list never waits on the network.
+func worktreeStateCheck(items []ui.WorktreeDisplay, mains []string) func(i int) ui.WorktreeState {
+	var mu sync.Mutex
+	var locks parallel.KeyedMutex
+	targets := make(map[string]git.MergeTarget)
+	targetFor := func(repoDir string) git.MergeTarget {
+		defer locks.Lock(repoDir)()
+		mu.Lock()
+		t, ok := targets[repoDir]
+		mu.Unlock()
+		if !ok {
+			remote, branch, _ := config.MergeCheckFor(repoDir)
+			t = git.ResolveMergeTarget(repoDir, remote, branch)
+			mu.Lock()
+			targets[repoDir] = t
+			mu.Unlock()
+		}
+		return t
+	}
+
+	return func(i int) ui.WorktreeState {
+		d := items[i].Path
+		status := git.CheckWorktreeStatus(d)
+		st := ui.WorktreeState{
+			Dirty:    len(status.Files),
+			Unpushed: len(status.Unpushed),
+			Behind:   status.Behind,
+			Activity: git.LastActivity(d, status),
+		}
+		if !st.Activity.IsZero() {
+			st.Age = ago(st.Activity)
+		}
+		if repo, branch := mains[i], status.Branch; repo != "" && branch != "" && !isProtected(repo, branch) {
+			st.Merged = git.MergeStatusAgainst(repo, branch, targetFor(repo)).Merged()
+		}
+		if st.Merged {
+			// Without an upstream every commit not on a remote counts as
+			// unpushed, which for a merged branch are the default branch's
+			st.Unpushed = 0
+		}
+		return st
+	}
+}
+
+// removeFromBrowser removes a worktree picked with ctrl+d in a list that
+// isn't for removing, after asking; a stray keypress shouldn't remove a
+// clean worktree and its merged branch without a word.
+func removeFromBrowser(path string, direct bool) {
+	ok, err := ui.ConfirmRemove(filepath.Base(path))
+	if err != nil {
+		if direct {
+			handleAbort(err)
+		}
+		if !isAbort(err) {
+			ui.Error(err.Error())
+		}
+		return
+	}
+	if !ok {
+		ui.Muted("Kept worktree — no changes made")
+		return
+	}
+	removeOne(path, direct)
+}
+
+// openWorktree opens a worktree in the editor.
+func openWorktree(path string, direct bool) {
+	if err := editor.Open(path); err != nil {
+		if direct {
+			ui.Error(fmt.Sprintf("Could not open editor: %v", err))
+			os.Exit(1)
+		}
+		ui.Warn(fmt.Sprintf("Could not open editor: %v", err))
+		return
+	}
+	ui.Success(fmt.Sprintf("Opened: %s", filepath.Base(path)))
+}
@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/vanderhaka/treework/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var cdCmd = &cobra.Command{
+	Use:   "cd <name>",
+	Short: "Print a worktree's path",
+	Long: `Print the absolute path of a worktree so your shell can change into it:
+
+  cd "$(treework cd feature-auth)"`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeWorktreeNames,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		// The path is the only thing that may reach stdout, so warnings
+		// printed along the way don't end up in "$(treework cd ...)"
+		pathOut, os.Stdout = os.Stdout, os.Stderr
+		checkConfig(cmd, args)
+	},
+	Run: runCd,
+}
+
+// pathOut is where cd prints the path: the real stdout.
+var pathOut = os.Stdout
+
+func runCd(cmd *cobra.Command, args []string) {
+	roots := requireRoots()
+	if len(roots) == 0 {
+		os.Exit(1)
+	}
+
+	path, err := findWorktree(roots, args[0])
+	if err != nil {
+		ui.Error(err.Error())
+		os.Exit(1)
+	}
+	fmt.Fprintln(pathOut, path)
+}
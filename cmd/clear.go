@@ -1,20 +1,37 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 
-	"github.com/charmbracelet/huh/spinner"
 	"github.com/vanderhaka/treework/internal/git"
+	"github.com/vanderhaka/treework/internal/journal"
+	"github.com/vanderhaka/treework/internal/parallel"
 	"github.com/vanderhaka/treework/internal/ui"
 	"github.com/spf13/cobra"
 )
 
 var clearCmd = &cobra.Command{
 	Use:   "clear",
-	Short: "Remove ALL worktrees for a repo",
-	Run:   runClear,
+	Short: "Choose worktrees to remove for a repo",
+	Long: `Choose worktrees to remove for a repo. Clean worktrees whose branch is
+merged are pre-selected.
+
+With --all-repos, every repo in your base folders is cleared at once after a
+single confirmation. Worktrees with unsaved work, locked worktrees (unless
+--force-locked is passed) and the one you're in are skipped; merged branches
+are deleted and others kept.`,
+	Args: cobra.NoArgs,
+	Run:  runClear,
+}
+
+var flagClearAllRepos bool
+
+func init() {
+	clearCmd.Flags().BoolVar(&flagClearAllRepos, "all-repos", false, "remove worktrees across every repo in the base folders")
 }
 
 // runClearInteractive is called from the root menu loop.
@@ -23,6 +40,14 @@ func runClearInteractive(cmd *cobra.Command) {
 }
 
 func runClear(cmd *cobra.Command, args []string) {
+	if flagClearAllRepos {
+		if flagRepo != "" {
+			ui.Error("--repo and --all-repos can't be used together")
+			os.Exit(1)
+		}
+		doClearAllRepos()
+		return
+	}
 	doClear(true)
 }
 
@@ -55,41 +80,121 @@ func doClear(direct bool) {
 		return
 	}
 
-	// 3. Display list
-	ui.Info(fmt.Sprintf("Worktrees for %s:", ui.BoldStyle.Render(repoName)))
+	// Locked worktrees are left alone unless --force-locked is passed
+	if !flagForceLocked {
+		var unlocked, locked []git.WorktreeInfo
+		for _, wt := range worktrees {
+			if wt.Locked {
+				locked = append(locked, wt)
+			} else {
+				unlocked = append(unlocked, wt)
+			}
+		}
+		if len(locked) > 0 {
+			ui.Warn(fmt.Sprintf("Skipping %d locked worktree(s):", len(locked)))
+			for _, wt := range locked {
+				ui.Muted(fmt.Sprintf("  • %s%s", filepath.Base(wt.Path), lockSuffix(wt.LockReason)))
+			}
+			ui.Muted("Pass --force-locked to remove them too")
+			fmt.Println()
+		}
+		worktrees = unlocked
+		if len(worktrees) == 0 {
+			ui.Info("No unlocked worktrees to remove.")
+			fmt.Println()
+			return
+		}
+	}
+
+	// The worktree the shell is in can't be removed from under it
 	for _, wt := range worktrees {
-		ui.Muted(fmt.Sprintf("%s  %s", filepath.Base(wt.Path), ui.MutedStyle.Render("("+wt.Branch+")")))
+		if inCurrentDir(wt.Path) {
+			ui.Warn(fmt.Sprintf("Skipping %s — you're inside it", filepath.Base(wt.Path)))
+			worktrees = withoutPaths(worktrees, []string{wt.Path})
+			fmt.Println()
+			break
+		}
 	}
-	fmt.Println()
+	if len(worktrees) == 0 {
+		ui.Info("No other worktrees to remove.")
+		fmt.Println()
+		return
+	}
+
+	// 3. Check each worktree: clean and merged ones are pre-selected
+	target := mergeTarget(repoDir)
+	var checked []worktreeCheck
+	if runCancellable("Checking worktrees...", func(ctx context.Context) {
+		checked, _ = parallel.Map(ctx, parallel.Limit(), worktrees, func(wt git.WorktreeInfo) worktreeCheck {
+			return checkWorktree(repoDir, wt, target)
+		})
+	}) {
+		ui.Muted("Cancelled.")
+		fmt.Println()
+		return
+	}
+	checks := make(map[string]worktreeCheck) // Worktree path → check
+	var choices []ui.RemovalChoice
+	for i, wt := range worktrees {
+		c := checked[i]
+		checks[wt.Path] = c
 
-	// 4. Safety check: identify dirty worktrees
-	type worktreeCheck struct {
-		info   git.WorktreeInfo
-		status git.WorktreeStatus
+		choice := ui.RemovalChoice{Path: wt.Path, Branch: wt.Branch, Checked: !c.unsaved && c.merge.Merged()}
+		switch {
+		case c.unsaved:
+			choice.Note, choice.Unsaved = c.status.Summary(), true
+		case c.merge.Merged():
+			choice.Note = c.merge.Describe()
+		case wt.Branch != "" && wt.Branch != "HEAD":
+			choice.Note = "not merged"
+		}
+		if wt.Locked {
+			choice.Note += "  locked" + lockSuffix(wt.LockReason)
+		}
+		choices = append(choices, choice)
 	}
-	var dirty []worktreeCheck
+
+	// 4. Let the user choose
+	ui.Info(fmt.Sprintf("Worktrees for %s:", ui.BoldStyle.Render(repoName)))
+	chosen, err := ui.SelectWorktreesToRemove(choices)
+	if err != nil {
+		if isAbort(err) {
+			if direct {
+				handleAbort(err)
+			}
+			return
+		}
+		ui.Error(err.Error())
+		if direct {
+			os.Exit(1)
+		}
+		return
+	}
+	if len(chosen) == 0 {
+		ui.Muted("Nothing selected.")
+		fmt.Println()
+		return
+	}
+	var selected []git.WorktreeInfo
 	for _, wt := range worktrees {
-		s := git.CheckWorktreeStatus(wt.Path)
-		entry := worktreeCheck{info: wt, status: s}
-		if s.IsDirty() {
-			dirty = append(dirty, entry)
+		if slices.Contains(chosen, wt.Path) {
+			selected = append(selected, wt)
 		}
 	}
+	worktrees = selected
 
-	// 5. Show dirty worktree warnings
+	// 5. Show dirty worktree warnings for the chosen set
+	var dirty []worktreeCheck
+	for _, wt := range worktrees {
+		if c := checks[wt.Path]; c.unsaved {
+			dirty = append(dirty, c)
+		}
+	}
 	if len(dirty) > 0 {
 		fmt.Println()
-		ui.Warn(fmt.Sprintf("%d worktree(s) have unsaved work:", len(dirty)))
+		ui.Warn(fmt.Sprintf("%d selected worktree(s) have unsaved work:", len(dirty)))
 		for _, d := range dirty {
-			reasons := ""
-			if d.status.HasUncommittedChanges && d.status.HasUnpushedCommits {
-				reasons = "uncommitted changes + unpushed commits"
-			} else if d.status.HasUncommittedChanges {
-				reasons = "uncommitted changes"
-			} else {
-				reasons = "unpushed commits"
-			}
-			ui.Muted(fmt.Sprintf("  • %s (%s) — %s", filepath.Base(d.info.Path), d.info.Branch, reasons))
+			ui.Muted(fmt.Sprintf("  • %s (%s) — %s", filepath.Base(d.info.Path), d.info.Branch, d.status.Summary()))
 		}
 		fmt.Println()
 	}
@@ -97,9 +202,9 @@ func doClear(direct bool) {
 	// 6. Confirm removal
 	var confirmed bool
 	if len(dirty) > 0 {
-		confirmed, err = ui.Confirm(fmt.Sprintf("Remove all %d worktrees? Unsaved work will be permanently lost", len(worktrees)))
+		confirmed, err = ui.Confirm(fmt.Sprintf("Remove %d worktree(s)? Unsaved work will be permanently lost", len(worktrees)))
 	} else {
-		confirmed, err = ui.Confirm(fmt.Sprintf("Remove all %d worktrees?", len(worktrees)))
+		confirmed, err = ui.Confirm(fmt.Sprintf("Remove %d worktree(s)?", len(worktrees)))
 	}
 	if err != nil {
 		if isAbort(err) {
@@ -120,69 +225,82 @@ func doClear(direct bool) {
 		return
 	}
 
-	// 7. Remove each worktree, tracking failures and unmerged branches
-	var failed []string
-	var unmergedBranches []string
-
-	err = spinner.New().
-		Title("Removing worktrees...").
-		Action(func() {
-			for _, wt := range worktrees {
-				// Use force only for dirty worktrees (user already confirmed)
-				status := git.CheckWorktreeStatus(wt.Path)
-				var removeErr error
-				if status.IsDirty() {
-					removeErr = git.WorktreeForceRemove(repoDir, wt.Path)
-				} else {
-					removeErr = git.WorktreeRemove(repoDir, wt.Path)
-				}
-
-				if removeErr != nil {
-					failed = append(failed, filepath.Base(wt.Path))
-					continue
-				}
-
-				// Branch cleanup
-				if wt.Branch != "" && wt.Branch != "main" && wt.Branch != "master" {
-					if git.IsBranchMerged(repoDir, wt.Branch) {
-						git.DeleteBranch(repoDir, wt.Branch)
-					} else {
-						unmergedBranches = append(unmergedBranches, wt.Branch)
-					}
-				}
-			}
-			git.WorktreePrune(repoDir)
-		}).
-		Run()
-
+	// Worktrees with processes the user won't stop are kept
+	var paths []string
+	for _, wt := range worktrees {
+		paths = append(paths, wt.Path)
+	}
+	busy, err := stopProcessesIn(paths)
 	if err != nil {
-		if isAbort(err) {
+		if direct {
+			handleAbort(err)
+		}
+		if !isAbort(err) {
+			ui.Error(err.Error())
+			ui.Muted("Nothing was removed.")
 			if direct {
-				handleAbort(err)
+				os.Exit(1)
 			}
-			return
-		}
-		ui.Error(err.Error())
-		if direct {
-			os.Exit(1)
 		}
 		return
 	}
+	if len(busy) > 0 {
+		worktrees = withoutPaths(worktrees, busy)
+		ui.Warn(fmt.Sprintf("Keeping %d worktree(s) with processes still running", len(busy)))
+		if len(worktrees) == 0 {
+			fmt.Println()
+			return
+		}
+	}
 
-	fmt.Println()
-
-	// Report failures
-	if len(failed) > 0 {
-		ui.Warn(fmt.Sprintf("Failed to remove %d worktree(s):", len(failed)))
-		for _, f := range failed {
-			ui.Muted(fmt.Sprintf("  • %s", f))
+	// Snapshot unsaved work first; a worktree whose backup fails is kept
+	backupIDs := make(map[string]string) // Worktree path → backup ID
+	if !flagNoBackup && len(dirty) > 0 {
+		var kept []string
+		for _, d := range dirty {
+			if slices.Contains(busy, d.info.Path) {
+				continue
+			}
+			if id, ok := backupBeforeRemove(repoDir, d.info.Path, d.info.Branch); ok {
+				backupIDs[d.info.Path] = id
+			} else {
+				kept = append(kept, d.info.Path)
+			}
+		}
+		if len(kept) > 0 {
+			worktrees = withoutPaths(worktrees, kept)
+			ui.Warn(fmt.Sprintf("Keeping %d worktree(s) that couldn't be backed up (pass --no-backup to remove them anyway)", len(kept)))
 		}
+		fmt.Println()
+	}
+
+	for _, wt := range worktrees {
+		runPreRemoveHook(wt.Path, repoDir, wt.Branch)
 	}
 
-	removed := len(worktrees) - len(failed)
-	if removed > 0 {
-		ui.Success(fmt.Sprintf("Removed %d worktree(s)", removed))
-		ui.Muted("Merged branches were auto-deleted")
+	// 7. Remove each worktree, showing how each one is getting on
+	results := make([]clearResult, len(worktrees))
+	items := make([]ui.ProgressItem, len(worktrees))
+	for i, wt := range worktrees {
+		results[i] = clearResult{info: wt, err: context.Canceled}
+		items[i] = ui.ProgressItem{Name: filepath.Base(wt.Path)}
+	}
+	var locks parallel.KeyedMutex
+	progress := ui.NewProgress("Removing worktrees", items)
+	progress.Run(func(ctx context.Context) {
+		parallel.ForEach(ctx, parallel.Limit(), len(worktrees), func(i int) {
+			wt := worktrees[i]
+			results[i] = removeWorktree(repoDir, checks[wt.Path], backupIDs[wt.Path], &locks, func(state ui.ItemState, detail string) {
+				progress.Update(i, state, detail)
+			})
+		})
+		git.WorktreePrune(repoDir)
+	})
+	var unmergedBranches []string
+	for _, r := range results {
+		if r.unmerged {
+			unmergedBranches = append(unmergedBranches, r.info.Branch)
+		}
 	}
 
 	// Handle unmerged branches
@@ -204,9 +322,23 @@ func doClear(direct bool) {
 			}
 		}
 		if forceDelete {
+			byBranch := make(map[string]git.WorktreeInfo)
+			for _, wt := range worktrees {
+				byBranch[wt.Branch] = wt
+			}
 			for _, b := range unmergedBranches {
+				wt := byBranch[b]
+				if backupIDs[wt.Path] == "" && !flagNoBackup {
+					id, ok := backupBeforeRemove(repoDir, wt.Path, b)
+					if !ok {
+						ui.Muted(fmt.Sprintf("Kept branch '%s'", b))
+						continue
+					}
+					backupIDs[wt.Path] = id
+				}
 				if err := git.ForceDeleteBranch(repoDir, b); err == nil {
 					ui.Success(fmt.Sprintf("Deleted branch '%s'", b))
+					record(journal.Entry{Op: journal.OpBranchDelete, Repo: repoDir, Branch: b, SHA: wt.Head, Backup: backupIDs[wt.Path]})
 				} else {
 					ui.Warn(fmt.Sprintf("Failed to delete branch '%s'", b))
 				}
@@ -218,3 +350,306 @@ func doClear(direct bool) {
 
 	fmt.Println()
 }
+
+// repoClear is one repo's part of clear --all-repos.
+type repoClear struct {
+	repo    string
+	remove  []worktreeCheck
+	skipped []string // "name — reason" for worktrees left alone
+}
+
+// doClearAllRepos removes the linked worktrees of every repo in the base
+// folders with one confirmation. Only worktrees that can go without losing
+// work are removed; merged branches are deleted and the rest kept.
+func doClearAllRepos() {
+	fmt.Println()
+
+	roots := requireRoots()
+	if len(roots) == 0 {
+		os.Exit(1)
+	}
+
+	// 1. Check every worktree of every repo
+	type job struct {
+		rc     *repoClear
+		wt     git.WorktreeInfo
+		target git.MergeTarget
+	}
+	var plan []*repoClear
+	var jobs []job
+	for _, repo := range refreshRepos(roots) {
+		worktrees := git.WorktreeList(repo)
+		if len(worktrees) == 0 {
+			continue
+		}
+		rc := &repoClear{repo: repo}
+		plan = append(plan, rc)
+		target := mergeTarget(repo)
+		for _, wt := range worktrees {
+			name := filepath.Base(wt.Path)
+			switch {
+			case wt.Locked && !flagForceLocked:
+				rc.skipped = append(rc.skipped, fmt.Sprintf("%s — skipped, locked%s", name, lockSuffix(wt.LockReason)))
+			case inCurrentDir(wt.Path):
+				rc.skipped = append(rc.skipped, fmt.Sprintf("%s — skipped, you're inside it", name))
+			default:
+				jobs = append(jobs, job{rc: rc, wt: wt, target: target})
+			}
+		}
+	}
+
+	var checked []worktreeCheck
+	if runCancellable("Checking worktrees...", func(ctx context.Context) {
+		checked, _ = parallel.Map(ctx, parallel.Limit(), jobs, func(j job) worktreeCheck {
+			return checkWorktree(j.rc.repo, j.wt, j.target)
+		})
+	}) {
+		ui.Muted("Cancelled.")
+		fmt.Println()
+		return
+	}
+	total := 0
+	for i, j := range jobs {
+		c := checked[i]
+		if c.unsaved {
+			j.rc.skipped = append(j.rc.skipped, fmt.Sprintf("%s — skipped, unsaved: %s", filepath.Base(c.info.Path), c.status.Summary()))
+			continue
+		}
+		j.rc.remove = append(j.rc.remove, c)
+		total++
+	}
+
+	if len(plan) == 0 {
+		ui.Info("No worktrees to remove.")
+		fmt.Println()
+		return
+	}
+
+	// 2. One summary, grouped by repo
+	for _, rc := range plan {
+		ui.Info(ui.BoldStyle.Render(filepath.Base(rc.repo)))
+		for _, c := range rc.remove {
+			var note string
+			switch {
+			case c.info.Branch == "" || c.info.Branch == "HEAD":
+				note = "detached"
+			case isProtected(rc.repo, c.info.Branch):
+				note = "protected branch kept"
+			case c.merge.Merged():
+				note = c.merge.Describe() + ", branch deleted"
+			default:
+				note = "not merged, branch kept"
+			}
+			ui.Muted(fmt.Sprintf("  • %s  (%s)  %s", filepath.Base(c.info.Path), c.info.Branch, note))
+		}
+		for _, s := range rc.skipped {
+			ui.Muted(fmt.Sprintf("  • %s", s))
+		}
+	}
+	fmt.Println()
+
+	if total == 0 {
+		ui.Info("Nothing can be removed without losing work.")
+		fmt.Println()
+		return
+	}
+
+	// 3. Confirm once
+	repos := 0
+	for _, rc := range plan {
+		if len(rc.remove) > 0 {
+			repos++
+		}
+	}
+	ok, err := ui.Confirm(fmt.Sprintf("Remove %d worktree(s) across %d repo(s)?", total, repos))
+	if err != nil {
+		handleAbort(err)
+	}
+	if !ok {
+		ui.Muted("Cancelled.")
+		fmt.Println()
+		return
+	}
+
+	// Worktrees with processes the user won't stop are kept
+	var paths []string
+	for _, rc := range plan {
+		for _, c := range rc.remove {
+			paths = append(paths, c.info.Path)
+		}
+	}
+	busy, err := stopProcessesIn(paths)
+	if err != nil {
+		handleAbort(err)
+		ui.Error(err.Error())
+		ui.Muted("Nothing was removed.")
+		os.Exit(1)
+	}
+	if len(busy) > 0 {
+		for _, rc := range plan {
+			var keep []worktreeCheck
+			for _, c := range rc.remove {
+				if !slices.Contains(busy, c.info.Path) {
+					keep = append(keep, c)
+				}
+			}
+			rc.remove = keep
+		}
+		ui.Warn(fmt.Sprintf("Keeping %d worktree(s) with processes still running", len(busy)))
+	}
+
+	for _, rc := range plan {
+		for _, c := range rc.remove {
+			runPreRemoveHook(c.info.Path, rc.repo, c.info.Branch)
+		}
+	}
+
+	// 4. Remove, showing each worktree's progress grouped by repo
+	type removal struct {
+		repo  string
+		check worktreeCheck
+	}
+	var removals []removal
+	var items []ui.ProgressItem
+	for _, rc := range plan {
+		for _, c := range rc.remove {
+			removals = append(removals, removal{rc.repo, c})
+			items = append(items, ui.ProgressItem{Name: filepath.Base(c.info.Path), Group: filepath.Base(rc.repo)})
+		}
+	}
+	var locks parallel.KeyedMutex
+	progress := ui.NewProgress("Removing worktrees", items)
+	progress.Run(func(ctx context.Context) {
+		parallel.ForEach(ctx, parallel.Limit(), len(removals), func(i int) {
+			r := removals[i]
+			removeWorktree(r.repo, r.check, "", &locks, func(state ui.ItemState, detail string) {
+				progress.Update(i, state, detail)
+			})
+		})
+		for _, rc := range plan {
+			if len(rc.remove) > 0 {
+				git.WorktreePrune(rc.repo)
+			}
+		}
+	})
+	fmt.Println()
+}
+
+// worktreeCheck is what clear found out about a worktree before removing it.
+type worktreeCheck struct {
+	info    git.WorktreeInfo
+	status  git.WorktreeStatus
+	merge   git.MergeStatus
+	unsaved bool // Removal would lose work
+}
+
+// checkWorktree inspects a worktree of repoDir for unsaved work and whether
+// its branch is merged into target.
+func checkWorktree(repoDir string, wt git.WorktreeInfo, target git.MergeTarget) worktreeCheck {
+	c := worktreeCheck{info: wt, status: git.CheckWorktreeStatus(wt.Path)}
+	if wt.Branch != "" && wt.Branch != "HEAD" {
+		c.merge = git.MergeStatusAgainst(repoDir, wt.Branch, target)
+	}
+	// Unpushed commits on a merged branch are already in the target
+	c.unsaved = c.status.IsDirty() && (c.status.HasUncommittedChanges || c.status.Operation != "" || !c.merge.Merged())
+	return c
+}
+
+// clearResult is what happened to a worktree clear tried to remove.
+type clearResult struct {
+	info     git.WorktreeInfo
+	err      error
+	branch   string // What happened to the branch
+	unmerged bool   // The branch was kept because it isn't merged
+}
+
+// removeWorktree removes a checked worktree, deletes its branch if it's
+// merged and not protected, and records both in the journal, calling report
+// as it goes. Worktrees checked as having unsaved work are force-removed, so
+// the user must have confirmed that; any other worktree that has picked up
+// changes since it was checked is left alone. Safe to call concurrently;
+// locks serialises the branch changes within a repo.
+func removeWorktree(repoDir string, c worktreeCheck, backupID string, locks *parallel.KeyedMutex, report func(ui.ItemState, string)) clearResult {
+	wt := c.info
+	r := clearResult{info: wt}
+	defer func() {
+		if r.err != nil {
+			report(ui.ItemFailed, r.err.Error())
+		} else {
+			report(ui.ItemDone, r.branch)
+		}
+	}()
+
+	report(ui.ItemChecking, "")
+	if wt.Locked {
+		if r.err = git.WorktreeUnlock(repoDir, wt.Path); r.err != nil {
+			return r
+		}
+		defer func() {
+			// The worktree is staying, so put its lock back
+			if r.err == nil {
+				return
+			}
+			if err := git.WorktreeLock(repoDir, wt.Path, wt.LockReason); err != nil {
+				r.err = fmt.Errorf("%w (and couldn't lock it again: %v)", r.err, err)
+			}
+		}()
+	}
+	force := c.unsaved
+	if !force {
+		if now := git.CheckWorktreeStatus(wt.Path); now.HasUncommittedChanges || now.Operation != "" {
+			what := now.ChangeSummary()
+			if what == "" {
+				what = now.Operation + " in progress"
+			}
+			r.err = fmt.Errorf("changed since check: %s", what)
+			return r
+		}
+	}
+
+	report(ui.ItemRemoving, "")
+	if force {
+		r.err = git.WorktreeForceRemove(repoDir, wt.Path)
+	} else {
+		r.err = git.WorktreeRemove(repoDir, wt.Path)
+	}
+	if r.err != nil {
+		return r
+	}
+
+	// Deleting branches takes the repo's ref locks, so one at a time per repo
+	defer locks.Lock(repoDir)()
+	record(journal.Entry{Op: journal.OpRemove, Repo: repoDir, Path: wt.Path, Branch: wt.Branch, SHA: wt.Head, Backup: backupID})
+
+	switch {
+	case wt.Branch == "" || wt.Branch == "HEAD":
+	case isProtected(repoDir, wt.Branch):
+		r.branch = fmt.Sprintf("kept protected branch '%s'", wt.Branch)
+	case git.RevParse(repoDir, "refs/heads/"+wt.Branch) != wt.Head:
+		// Commits made since the merge check aren't covered by it
+		r.branch = fmt.Sprintf("kept branch '%s' — changed since check", wt.Branch)
+	case c.merge.Merged():
+		report(ui.ItemDeletingBranch, wt.Branch)
+		if git.DeleteMergedBranch(repoDir, wt.Branch, wt.Head, c.merge) == nil {
+			r.branch = fmt.Sprintf("deleted branch '%s' (%s)", wt.Branch, c.merge.Describe())
+			record(journal.Entry{Op: journal.OpBranchDelete, Repo: repoDir, Branch: wt.Branch, SHA: wt.Head})
+		} else {
+			r.branch = fmt.Sprintf("couldn't delete branch '%s'", wt.Branch)
+		}
+	default:
+		r.branch = fmt.Sprintf("branch '%s' is not merged", wt.Branch)
+		r.unmerged = true
+	}
+	return r
+}
+
+// withoutPaths returns worktrees minus those at paths.
+func withoutPaths(worktrees []git.WorktreeInfo, paths []string) []git.WorktreeInfo {
+	var remaining []git.WorktreeInfo
+	for _, wt := range worktrees {
+		if !slices.Contains(paths, wt.Path) {
+			remaining = append(remaining, wt)
+		}
+	}
+	return remaining
+}
@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/vanderhaka/treework/internal/backup"
+	"github.com/vanderhaka/treework/internal/config"
+	"github.com/vanderhaka/treework/internal/git"
+	"github.com/vanderhaka/treework/internal/park"
+	"github.com/vanderhaka/treework/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var completionCmd = &cobra.Command{
+	Use:   "completion <bash|zsh|fish>",
+	Short: "Generate a shell completion script",
+	Long: `Generate a shell completion script for treework.
+
+  bash:  treework completion bash > ~/.local/share/bash-completion/completions/treework
+  zsh:   treework completion zsh > "${fpath[1]}/_treework"
+  fish:  treework completion fish > ~/.config/fish/completions/treework.fish`,
+	Args:                  cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	ValidArgs:             []string{"bash", "zsh", "fish"},
+	DisableFlagsInUseLine: true,
+	Run:                   runCompletion,
+}
+
+func runCompletion(cmd *cobra.Command, args []string) {
+	var err error
+	switch args[0] {
+	case "bash":
+		err = rootCmd.GenBashCompletionV2(os.Stdout, true)
+	case "zsh":
+		err = rootCmd.GenZshCompletion(os.Stdout)
+	case "fish":
+		err = rootCmd.GenFishCompletion(os.Stdout, true)
+	}
+	if err != nil {
+		ui.Error(fmt.Sprintf("Failed to generate completions: %v", err))
+		os.Exit(1)
+	}
+}
+
+// Completion functions must stay quiet and fast: they never print, never
+// prompt, and read from git metadata rather than running full checks.
+
+// completeWorktreeNames completes worktree folder names. Inside a repo it reads
+// the worktree list straight from git; otherwise it scans the base folders.
+func completeWorktreeNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var names []string
+	if repo := git.CurrentRepo(); repo != "" {
+		for _, wt := range git.WorktreeList(repo) {
+			names = append(names, filepath.Base(wt.Path)+"\t"+wt.Branch)
+		}
+		if len(names) > 0 {
+			return names, cobra.ShellCompDirectiveNoFileComp
+		}
+	}
+
+	for _, d := range worktreeDirs(config.RootPaths()) {
+		names = append(names, filepath.Base(d))
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeBranches completes local and remote branch names for the repo
+// selected by --repo, or the current repo.
+func completeBranches(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	repo := git.CurrentRepo()
+	if flagRepo != "" {
+		// Only the cached index: scanning the base folders is too slow here
+		repo, _ = lookupRepo(flagRepo, true)
+	}
+	if repo == "" {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	seen := make(map[string]bool)
+	var branches []string
+	for _, b := range git.LocalBranches(repo) {
+		if !seen[b] {
+			seen[b] = true
+			branches = append(branches, b+"\tlocal")
+		}
+	}
+	for _, b := range git.RemoteBranches(repo) {
+		if !seen[b] {
+			seen[b] = true
+			branches = append(branches, b+"\tremote")
+		}
+	}
+	return branches, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeRepoNames completes repo aliases and repo folder names in the base
+// folders for --repo.
+func completeRepoNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	seen := make(map[string]bool)
+	var names []string
+	for name, dir := range config.RepoAliases() {
+		seen[name] = true
+		names = append(names, name+"\t"+dir)
+	}
+	for _, r := range scanRepos(config.RootPaths()) {
+		name := filepath.Base(r)
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name+"\t"+r)
+		}
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeBackups completes backup IDs, newest last.
+func completeBackups(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var ids []string
+	for _, r := range scanRepos(config.RootPaths()) {
+		for _, b := range backup.List(r) {
+			ids = append(ids, b.ID+"\t"+ago(b.Created))
+		}
+	}
+	return ids, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeParked completes the names of parked worktrees.
+func completeParked(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var names []string
+	for _, p := range park.List() {
+		names = append(names, p.Name+"\t"+p.Branch)
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeRoots completes the configured base folders for --root.
+func completeRoots(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return config.RootPaths(), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeProfiles completes profile names for --profile.
+func completeProfiles(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return config.ProfileNames(), cobra.ShellCompDirectiveNoFileComp
+}
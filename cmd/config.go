@@ -0,0 +1,210 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/vanderhaka/treework/internal/config"
+	"github.com/vanderhaka/treework/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Read and write settings from scripts",
+	Long: `Read and write treework settings without the interactive menu.
+
+Use 'treework settings' for the interactive version.`,
+}
+
+var configGetCmd = &cobra.Command{
+	Use:               "get <key>",
+	Short:             "Print the value of a setting",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeConfigKeys,
+	Run:               runConfigGet,
+}
+
+var configSetCmd = &cobra.Command{
+	Use:               "set <key> <value>",
+	Short:             "Change a setting",
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeConfigKeys,
+	Run:               runConfigSet,
+}
+
+var configUnsetCmd = &cobra.Command{
+	Use:               "unset <key>",
+	Short:             "Reset a setting to its default",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeConfigKeys,
+	Run:               runConfigUnset,
+}
+
+var configListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List all settings with where each value comes from",
+	Args:  cobra.NoArgs,
+	Run:   runConfigList,
+}
+
+var configPathCmd = &cobra.Command{
+	Use:   "path",
+	Short: "Print the config file path",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println(config.Path())
+	},
+}
+
+var configProfilesCmd = &cobra.Command{
+	Use:   "profiles",
+	Short: "List profiles, marking the active one",
+	Args:  cobra.NoArgs,
+	Run:   runConfigProfiles,
+}
+
+var (
+	flagConfigShowSource bool
+	flagConfigRoot       string
+	flagConfigGlobal     bool
+)
+
+func init() {
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configUnsetCmd)
+	configCmd.AddCommand(configListCmd)
+	configCmd.AddCommand(configPathCmd)
+	configCmd.AddCommand(configProfilesCmd)
+
+	configGetCmd.Flags().BoolVar(&flagConfigShowSource, "show-source", false, "also print where the value comes from")
+	for _, c := range []*cobra.Command{configGetCmd, configSetCmd, configUnsetCmd, configListCmd} {
+		c.Flags().StringVar(&flagConfigRoot, "root", "", "read or write the setting for one base folder")
+		c.Flags().BoolVar(&flagConfigGlobal, "global", false, "ignore the active profile and use the top-level settings")
+		c.RegisterFlagCompletionFunc("root", completeRoots)
+	}
+}
+
+// configScope builds the scope for config commands from --root, --global
+// and the active profile.
+func configScope() config.Scope {
+	scope := config.Scope{Root: flagConfigRoot}
+	if !flagConfigGlobal {
+		scope.Profile = config.ActiveProfile()
+	}
+	return scope
+}
+
+func runConfigGet(cmd *cobra.Command, args []string) {
+	value, source, err := config.Value(args[0], configScope())
+	if err != nil {
+		ui.Error(err.Error())
+		os.Exit(1)
+	}
+	if flagConfigShowSource {
+		fmt.Printf("%s\t(%s)\n", value, source)
+		return
+	}
+	fmt.Println(value)
+}
+
+func runConfigSet(cmd *cobra.Command, args []string) {
+	stored, err := config.Set(args[0], args[1], configScope())
+	if err != nil {
+		ui.Error(fmt.Sprintf("Invalid %s: %v", args[0], err))
+		os.Exit(1)
+	}
+	ui.Success(fmt.Sprintf("%s set to %s", args[0], stored))
+	warnIfDeprecated(args[0])
+	warnIfOverridden(args[0])
+}
+
+func runConfigUnset(cmd *cobra.Command, args []string) {
+	if err := config.Unset(args[0], configScope()); err != nil {
+		ui.Error(err.Error())
+		os.Exit(1)
+	}
+	ui.Success(fmt.Sprintf("%s reset to default", args[0]))
+	warnIfDeprecated(args[0])
+	warnIfOverridden(args[0])
+}
+
+func runConfigList(cmd *cobra.Command, args []string) {
+	scope := configScope()
+	if scope.Profile != "" {
+		fmt.Println(ui.MutedStyle.Render(fmt.Sprintf("# profile: %s", scope.Profile)))
+	}
+	if scope.Root != "" {
+		fmt.Println(ui.MutedStyle.Render(fmt.Sprintf("# base folder: %s", scope.Root)))
+	}
+	for _, k := range config.Keys() {
+		if scope.Root != "" && !k.PerRoot {
+			continue
+		}
+		value, source, _ := config.Value(k.Name, scope)
+		if value == "" {
+			value = "-"
+		}
+		fmt.Printf("%s = %s %s\n", ui.BoldStyle.Render(k.Name), value, ui.MutedStyle.Render("("+source+")"))
+	}
+}
+
+func runConfigProfiles(cmd *cobra.Command, args []string) {
+	active := config.ActiveProfile()
+	names := config.ProfileNames()
+	if len(names) == 0 {
+		ui.Muted("No profiles defined. Create one with 'treework config set --profile <name> roots <folders>'.")
+		return
+	}
+	for _, name := range names {
+		if name == active {
+			fmt.Println(ui.SuccessStyle.Render("* ") + name)
+		} else {
+			fmt.Println("  " + name)
+		}
+	}
+}
+
+// warnIfDeprecated points to the key that replaced an old one.
+func warnIfDeprecated(name string) {
+	if k, err := config.LookupKey(name); err == nil && k.Deprecated != "" {
+		ui.Muted(fmt.Sprintf("%s is deprecated — use %s", k.Name, k.Deprecated))
+	}
+}
+
+// warnIfOverridden tells the user when an env var hides the value they just changed.
+func warnIfOverridden(name string) {
+	k, err := config.LookupKey(name)
+	if err != nil || k.Env == "" {
+		return
+	}
+	if os.Getenv(k.Env) != "" {
+		ui.Warn(fmt.Sprintf("%s is set in your environment and takes priority over the config file", k.Env))
+	}
+}
+
+// completeConfigKeys completes key names, then sensible values for 'config set'.
+func completeConfigKeys(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) == 0 {
+		var names []string
+		for _, k := range config.Keys() {
+			names = append(names, k.Name+"\t"+k.Description)
+		}
+		return names, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	if cmd.Name() != "set" || len(args) > 1 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	switch args[0] {
+	case "roots":
+		return nil, cobra.ShellCompDirectiveFilterDirs
+	case "profile":
+		return config.ProfileNames(), cobra.ShellCompDirectiveNoFileComp
+	case "editor":
+		return []string{"cursor", "code", "zed", "subl", "vim", "nvim"}, cobra.ShellCompDirectiveNoFileComp
+	}
+	return nil, cobra.ShellCompDirectiveNoFileComp
+}
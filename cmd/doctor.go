@@ -0,0 +1,366 @@
+package cmd
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/huh/spinner"
+	"github.com/vanderhaka/treework/internal/git"
+	"github.com/vanderhaka/treework/internal/journal"
+	"github.com/vanderhaka/treework/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Find and repair broken worktree state",
+	Long: `Cross-check the worktree folders in your base folders against what git
+has registered for each repo, report every inconsistency and offer to fix it.`,
+	Args: cobra.NoArgs,
+	Run:  runDoctor,
+}
+
+var flagDoctorDryRun bool
+
+func init() {
+	doctorCmd.Flags().BoolVar(&flagDoctorDryRun, "dry-run", false, "only report problems, don't offer fixes")
+}
+
+// Issue severities, most serious first.
+const (
+	severityError   = "error"   // git state is wrong (e.g. a branch stuck checked out)
+	severityWarning = "warning" // leftovers that waste space or confuse treework
+)
+
+// doctorIssue is one inconsistency found by doctor, with an optional fix.
+type doctorIssue struct {
+	severity string
+	repo     string // Main repo, if known
+	path     string // Worktree folder
+	problem  string
+	fix      string       // Describes the fix, "" if there is none
+	apply    func() error // Applies the fix
+	confirm  func() bool  // Extra safety check before apply; false skips the fix
+}
+
+func runDoctor(cmd *cobra.Command, args []string) {
+	fmt.Println()
+
+	roots := requireRoots()
+	if len(roots) == 0 {
+		os.Exit(1)
+	}
+
+	var issues []doctorIssue
+	err := spinner.New().
+		Title("Checking worktrees...").
+		Action(func() {
+			issues = diagnose(roots)
+		}).
+		Run()
+	if err != nil {
+		handleAbort(err)
+	}
+
+	if len(issues) == 0 {
+		ui.Success("No problems found")
+		fmt.Println()
+		return
+	}
+
+	ui.Warn(fmt.Sprintf("Found %d problem(s):", len(issues)))
+	fmt.Println()
+	for i, is := range issues {
+		label := ui.WarnStyle.Render(is.severity)
+		if is.severity == severityError {
+			label = ui.ErrorStyle.Render(is.severity)
+		}
+		fmt.Printf("  %d. [%s] %s\n", i+1, label, is.problem)
+		ui.Muted("   " + is.path)
+		if is.fix != "" {
+			ui.Muted("   fix: " + is.fix)
+		}
+	}
+	fmt.Println()
+
+	if flagDoctorDryRun {
+		return
+	}
+
+	fixed := 0
+	for _, is := range issues {
+		if is.apply == nil {
+			continue
+		}
+		ok, err := ui.Confirm(fmt.Sprintf("%s — %s?", filepath.Base(is.path), is.fix))
+		if err != nil {
+			handleAbort(err)
+		}
+		if !ok || (is.confirm != nil && !is.confirm()) {
+			ui.Muted("Skipped")
+			continue
+		}
+		if err := is.apply(); err != nil {
+			ui.Error(fmt.Sprintf("Fix failed: %v", err))
+			continue
+		}
+		ui.Success("Fixed")
+		fixed++
+	}
+
+	fmt.Println()
+	if fixed > 0 {
+		ui.Success(fmt.Sprintf("Fixed %d of %d problem(s)", fixed, len(issues)))
+	}
+	fmt.Println()
+}
+
+// diagnose cross-checks worktree folders on disk with every repo's worktree list.
+func diagnose(roots []string) []doctorIssue {
+	repos := refreshRepos(roots)
+	lists := make(map[string][]git.WorktreeInfo)
+	tracked := make(map[string]bool)
+	for _, repo := range repos {
+		lists[repo] = git.WorktreeList(repo)
+		for _, wt := range lists[repo] {
+			tracked[filepath.Clean(wt.Path)] = true
+		}
+	}
+
+	// 1. Worktree folders on disk that no repo has registered
+	var orphans []doctorIssue
+	moved := make(map[string]bool) // Old paths of folders that were moved by hand
+	byName := make(map[string][]string)
+	for _, r := range repos {
+		byName[filepath.Base(r)] = append(byName[filepath.Base(r)], r)
+	}
+	for _, d := range worktreeDirs(roots) {
+		if tracked[filepath.Clean(d)] {
+			continue
+		}
+		if old := registeredPath(d); old != "" {
+			moved[old] = true
+		}
+		orphans = append(orphans, diagnoseOrphan(d, byName))
+	}
+
+	// 2. Registered worktrees whose folder is gone or whose link is broken
+	var issues []doctorIssue
+	for _, repo := range repos {
+		for _, wt := range lists[repo] {
+			repo, wt := repo, wt
+
+			if _, err := os.Stat(wt.Path); err != nil {
+				if moved[filepath.Clean(wt.Path)] {
+					continue // reported (and repaired) at its new location
+				}
+				problem := "Registered worktree folder is missing"
+				if wt.Branch != "" {
+					problem = fmt.Sprintf("Branch '%s' is checked out in a worktree folder that no longer exists", wt.Branch)
+				}
+				fix := "prune the stale entry so the branch is free again"
+				if wt.Locked {
+					// git worktree prune leaves locked entries alone
+					problem += " — it's locked" + lockSuffix(wt.LockReason) + ", so it may be on a drive that isn't mounted"
+					fix = "unlock it and prune the stale entry so the branch is free again"
+				}
+				issues = append(issues, doctorIssue{
+					severity: severityError,
+					repo:     repo,
+					path:     wt.Path,
+					problem:  problem,
+					fix:      fix,
+					apply: func() error {
+						if wt.Locked {
+							if err := git.WorktreeUnlock(repo, wt.Path); err != nil {
+								return err
+							}
+						}
+						git.WorktreePrune(repo)
+						for _, still := range git.WorktreeList(repo) {
+							if filepath.Clean(still.Path) == filepath.Clean(wt.Path) {
+								return fmt.Errorf("git still lists %s after pruning", wt.Path)
+							}
+						}
+						record(journal.Entry{Op: journal.OpRemove, Repo: repo, Path: wt.Path, Branch: wt.Branch, SHA: wt.Head})
+						return nil
+					},
+				})
+				continue
+			}
+
+			if !linksTo(wt.Path, repo) {
+				issues = append(issues, doctorIssue{
+					severity: severityWarning,
+					repo:     repo,
+					path:     wt.Path,
+					problem:  "Worktree's .git file doesn't point back to its repo",
+					fix:      "run git worktree repair",
+					apply:    func() error { return git.WorktreeRepair(repo, wt.Path) },
+				})
+			}
+		}
+	}
+
+	return append(issues, orphans...)
+}
+
+// registeredPath returns the path git has on record for a worktree folder,
+// read from the repo side of its .git link, or "" if unknown.
+func registeredPath(dir string) string {
+	gitDir := git.LinkedGitDir(dir)
+	if gitDir == "" {
+		return ""
+	}
+	data, err := os.ReadFile(filepath.Join(gitDir, "gitdir"))
+	if err != nil {
+		return ""
+	}
+	return filepath.Dir(filepath.Clean(strings.TrimSpace(string(data))))
+}
+
+// linksTo reports whether a worktree's .git file leads to repo's git directory.
+func linksTo(wtPath, repo string) bool {
+	main := git.MainRepoDir(wtPath)
+	if main == "" {
+		return false
+	}
+	a, errA := filepath.EvalSymlinks(main)
+	b, errB := filepath.EvalSymlinks(repo)
+	return errA == nil && errB == nil && a == b
+}
+
+// diagnoseOrphan works out why git doesn't know about a worktree folder
+// and picks the least destructive fix.
+func diagnoseOrphan(dir string, reposByName map[string][]string) doctorIssue {
+	is := doctorIssue{severity: severityWarning, path: dir}
+
+	// Guess the repo: from the .git link if there is one, else the folder name
+	repoName := extractRepoName(filepath.Base(dir))
+	gitDir := git.LinkedGitDir(dir)
+	if gitDir != "" && filepath.Base(filepath.Dir(gitDir)) == "worktrees" {
+		common := filepath.Dir(filepath.Dir(gitDir))
+		if filepath.Base(common) == ".git" {
+			common = filepath.Dir(common)
+		}
+		repoName = filepath.Base(common)
+	}
+	var repo string
+	if candidates := reposByName[repoName]; len(candidates) == 1 {
+		repo = candidates[0]
+		is.repo = repo
+	}
+
+	gitDirExists := false
+	if gitDir != "" {
+		_, err := os.Stat(gitDir)
+		gitDirExists = err == nil
+	}
+
+	switch {
+	case gitDirExists:
+		// Folder was moved by hand; its repo still has the entry
+		is.problem = "Worktree folder was moved and git lost track of it"
+		is.fix = "run git worktree repair"
+		is.apply = func() error { return git.WorktreeRepair(git.MainRepoDir(dir), dir) }
+
+	case gitDir != "" && repo != "":
+		// Repo was moved (or the entry pruned); re-attach the folder to it
+		branch := strings.TrimPrefix(filepath.Base(dir), repoName+"-worktree-")
+		if !git.BranchExists(repo, branch) {
+			branch = git.DefaultBranch(repo)
+		}
+		is.problem = fmt.Sprintf("Worktree's .git file points to a location that no longer exists (repo now at %s?)", repo)
+		is.fix = fmt.Sprintf("re-register it with %s on branch '%s'", filepath.Base(repo), branch)
+		is.apply = func() error {
+			if err := git.WorktreeReregister(repo, dir, branch); err != nil {
+				return err
+			}
+			record(journal.Entry{Op: journal.OpCreate, Repo: repo, Path: dir, Branch: git.CurrentBranch(dir), SHA: git.RevParse(dir, "HEAD")})
+			return nil
+		}
+
+	default:
+		is.problem = "Folder looks like a worktree but no repo knows about it"
+		is.fix = "delete the folder"
+		is.apply = func() error { return deleteOrphan(repo, repoName, dir) }
+		is.confirm = func() bool { return confirmDeleteOrphan(repo, dir) }
+	}
+
+	return is
+}
+
+// deleteOrphan deletes an orphan worktree folder, first backing up its files
+// into repo (the repo it seems to belong to, if known) in case they hold work.
+func deleteOrphan(repo, repoName, dir string) error {
+	var backupID, branch, sha string
+	if repo != "" && countFiles(dir) > 0 {
+		// The backup's files sit on the branch the folder is named for, or
+		// the default branch, which is also where undo recreates it
+		branch = strings.TrimPrefix(filepath.Base(dir), repoName+"-worktree-")
+		if !git.BranchExists(repo, branch) {
+			branch = ""
+			sha = git.RevParse(repo, git.DefaultBranch(repo))
+		} else {
+			sha = git.RevParse(repo, "refs/heads/"+branch)
+		}
+		id, ok := backupBeforeRemove(repo, dir, branch)
+		if !ok {
+			return fmt.Errorf("nothing was deleted")
+		}
+		backupID = id
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	record(journal.Entry{Op: journal.OpRemove, Repo: repo, Path: dir, Branch: branch, SHA: sha, Backup: backupID})
+	return nil
+}
+
+// confirmDeleteOrphan warns about what's inside an orphan folder before it is
+// deleted, asking again if it contains anything that looks like work. When
+// the folder's repo is known its files are backed up before it's deleted.
+func confirmDeleteOrphan(repo, dir string) bool {
+	if git.IsLinkedWorktree(dir) {
+		if status := git.CheckWorktreeStatus(dir); status.IsDirty() {
+			ui.WarnDirtyWorktree(dirtyDetails(status))
+			ok, _ := ui.ConfirmDirtyRemove()
+			return ok
+		}
+		return true
+	}
+
+	files := countFiles(dir)
+	if files == 0 {
+		return true
+	}
+
+	ui.Warn(fmt.Sprintf("%s contains %d file(s) that git can't check for unsaved work.", filepath.Base(dir), files))
+	if repo != "" {
+		ok, _ := ui.Confirm(fmt.Sprintf("Delete it anyway? Its files are backed up to %s first", filepath.Base(repo)))
+		return ok
+	}
+	ok, _ := ui.Confirm("Delete it anyway? This cannot be undone")
+	return ok
+}
+
+// countFiles counts the files in dir, not counting dependencies or .git.
+func countFiles(dir string) int {
+	files := 0
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() && (d.Name() == "node_modules" || d.Name() == ".git") {
+			return fs.SkipDir
+		}
+		if !d.IsDir() {
+			files++
+		}
+		return nil
+	})
+	return files
+}
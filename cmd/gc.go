@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/vanderhaka/treework/internal/git"
+	"github.com/vanderhaka/treework/internal/journal"
+	"github.com/vanderhaka/treework/internal/parallel"
+	"github.com/vanderhaka/treework/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var gcCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "Remove worktrees whose branches have been merged",
+	Long: `Remove clean, unlocked worktrees whose branch has landed in the default
+branch — by merge commit, fast-forward, rebase merge or squash merge — and
+delete those branches. Without --repo every repo in your base folders is checked.`,
+	Args: cobra.NoArgs,
+	Run:  runGc,
+}
+
+var flagGcDryRun bool
+
+func init() {
+	gcCmd.Flags().BoolVar(&flagGcDryRun, "dry-run", false, "list what would be removed without removing anything")
+}
+
+// gcCandidate is a worktree gc can remove.
+type gcCandidate struct {
+	repo  string
+	info  git.WorktreeInfo
+	merge git.MergeStatus
+}
+
+func runGc(cmd *cobra.Command, args []string) {
+	fmt.Println()
+
+	var repos []string
+	if flagRepo != "" {
+		repo, err := repoByName(flagRepo)
+		if err != nil {
+			ui.Error(err.Error())
+			os.Exit(1)
+		}
+		repos = []string{repo}
+	} else {
+		roots := requireRoots()
+		if len(roots) == 0 {
+			os.Exit(1)
+		}
+		repos = refreshRepos(roots)
+	}
+
+	// Check every worktree with a branch that could be merged
+	type gcJob struct {
+		gcCandidate
+		target git.MergeTarget
+	}
+	var jobs []gcJob
+	for _, repo := range repos {
+		worktrees := git.WorktreeList(repo)
+		if len(worktrees) == 0 {
+			continue
+		}
+		target := mergeTarget(repo)
+		for _, wt := range worktrees {
+			if wt.Branch == "" || wt.Branch == "HEAD" || isProtected(repo, wt.Branch) {
+				continue
+			}
+			jobs = append(jobs, gcJob{gcCandidate{repo: repo, info: wt}, target})
+		}
+	}
+	type gcCheck struct {
+		merge git.MergeStatus
+		skip  bool
+	}
+	var checked []gcCheck
+	if runCancellable("Checking worktrees...", func(ctx context.Context) {
+		checked, _ = parallel.Map(ctx, parallel.Limit(), jobs, func(c gcJob) gcCheck {
+			merge := git.MergeStatusAgainst(c.repo, c.info.Branch, c.target)
+			if !merge.Merged() {
+				return gcCheck{merge: merge}
+			}
+			// Unpushed commits don't count: they're already in the target
+			s := git.CheckWorktreeStatus(c.info.Path)
+			return gcCheck{merge: merge, skip: c.info.Locked || s.HasUncommittedChanges || s.Operation != "" || inCurrentDir(c.info.Path)}
+		})
+	}) {
+		ui.Muted("Cancelled.")
+		fmt.Println()
+		return
+	}
+
+	var candidates []gcCandidate
+	skipped := 0
+	for i, j := range jobs {
+		switch {
+		case !checked[i].merge.Merged():
+		case checked[i].skip:
+			skipped++
+		default:
+			j.merge = checked[i].merge
+			candidates = append(candidates, j.gcCandidate)
+		}
+	}
+
+	if len(candidates) == 0 {
+		ui.Info("No merged worktrees to remove.")
+		if skipped > 0 {
+			ui.Muted(fmt.Sprintf("%d merged worktree(s) skipped because they're locked, have uncommitted changes or hold your current directory", skipped))
+		}
+		fmt.Println()
+		return
+	}
+
+	ui.Info("Merged worktrees:")
+	for _, c := range candidates {
+		ui.Muted(fmt.Sprintf("  • %s  (%s, %s)", filepath.Base(c.info.Path), c.info.Branch, c.merge.Describe()))
+	}
+	if skipped > 0 {
+		ui.Muted(fmt.Sprintf("%d more skipped because they're locked, have uncommitted changes or hold your current directory", skipped))
+	}
+	fmt.Println()
+	if flagGcDryRun {
+		return
+	}
+
+	ok, err := ui.Confirm(fmt.Sprintf("Remove %d worktree(s) and their branches?", len(candidates)))
+	if err != nil {
+		handleAbort(err)
+	}
+	if !ok {
+		ui.Muted("Cancelled.")
+		fmt.Println()
+		return
+	}
+
+	var paths []string
+	for _, c := range candidates {
+		paths = append(paths, c.info.Path)
+	}
+	busy, err := stopProcessesIn(paths)
+	if err != nil {
+		handleAbort(err)
+		ui.Error(err.Error())
+		ui.Muted("Nothing was removed.")
+		os.Exit(1)
+	}
+	if len(busy) > 0 {
+		var remaining []gcCandidate
+		for _, c := range candidates {
+			if !slices.Contains(busy, c.info.Path) {
+				remaining = append(remaining, c)
+			}
+		}
+		candidates = remaining
+		ui.Warn(fmt.Sprintf("Keeping %d worktree(s) with processes still running", len(busy)))
+	}
+
+	removed := 0
+	pruned := make(map[string]bool)
+	for _, c := range candidates {
+		runPreRemoveHook(c.info.Path, c.repo, c.info.Branch)
+		if err := git.WorktreeRemove(c.repo, c.info.Path); err != nil {
+			ui.Warn(fmt.Sprintf("Failed to remove %s: %v", filepath.Base(c.info.Path), err))
+			continue
+		}
+		removed++
+		record(journal.Entry{Op: journal.OpRemove, Repo: c.repo, Path: c.info.Path, Branch: c.info.Branch, SHA: c.info.Head})
+
+		if err := git.DeleteMergedBranch(c.repo, c.info.Branch, c.info.Head, c.merge); err != nil {
+			ui.Warn(fmt.Sprintf("Removed %s but couldn't delete branch '%s'", filepath.Base(c.info.Path), c.info.Branch))
+			continue
+		}
+		record(journal.Entry{Op: journal.OpBranchDelete, Repo: c.repo, Branch: c.info.Branch, SHA: c.info.Head})
+		ui.Success(fmt.Sprintf("Removed %s and branch '%s' (%s)", filepath.Base(c.info.Path), c.info.Branch, c.merge.Describe()))
+		pruned[c.repo] = true
+	}
+	for repo := range pruned {
+		git.WorktreePrune(repo)
+	}
+
+	fmt.Println()
+	ui.Success(fmt.Sprintf("Removed %d of %d worktree(s)", removed, len(candidates)))
+	fmt.Println()
+}
@@ -1,49 +1,250 @@
 package cmd
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/huh/spinner"
 	"github.com/vanderhaka/treework/internal/config"
+	"github.com/vanderhaka/treework/internal/frecency"
 	"github.com/vanderhaka/treework/internal/git"
+	"github.com/vanderhaka/treework/internal/repoindex"
 	"github.com/vanderhaka/treework/internal/ui"
 )
 
+// flagRepo holds the --repo flag shared by commands that work on a single repo.
+var flagRepo string
+
 // resolveRepo returns a repo directory.
+// An explicit --repo flag always wins.
 // When forceSelect is true (interactive menu), it always shows the project list.
 // When false (direct CLI), it tries the current directory first.
+// The repo is recorded as used, to rank it higher in the project list.
 func resolveRepo(forceSelect bool) (string, error) {
+	repo, err := pickRepo(forceSelect)
+	if err == nil {
+		frecency.Record(repo)
+	}
+	return repo, err
+}
+
+func pickRepo(forceSelect bool) (string, error) {
+	if flagRepo != "" {
+		return repoByName(flagRepo)
+	}
+
 	if !forceSelect {
 		if repo := git.CurrentRepo(); repo != "" {
 			return repo, nil
 		}
 	}
 
-	// Scan base folder for repos
-	devDir := requireDevDir()
-	if devDir == "" {
+	// Scan base folders for repos
+	roots := requireRoots()
+	if len(roots) == 0 {
 		return "", fmt.Errorf("no base folder configured")
 	}
 
-	repos := git.ScanRepos(devDir)
+	repos := scanRepos(roots)
 	if len(repos) == 0 {
-		return "", fmt.Errorf("no git repos found in %s — check your base folder in 'treework settings'", devDir)
+		return "", fmt.Errorf("no git repos found in %s — check your base folders in 'treework settings'", strings.Join(roots, ", "))
 	}
 
-	selected, err := ui.SelectRepo(repos)
+	selected, err := ui.SelectRepo(repoChoices(repos))
 	if err != nil {
-		if isAbort(err) {
-			return "", err
-		}
 		return "", err
 	}
 
 	return selected, nil
 }
 
+// repoChoices orders repos for the project list: favourites first, in the
+// order they were pinned, then by how often and recently they were used,
+// then by name.
+func repoChoices(repos []string) []ui.RepoChoice {
+	favorites := config.FavoriteRepos()
+	pinned := pinOrder(favorites)
+	aliases := aliasesByRepo()
+	scores := make(map[string]float64)
+	for r, s := range frecency.Scores() {
+		scores[realPath(r)] += s
+	}
+
+	// Pins, aliases and uses are stored as git reports them, with symlinks
+	// resolved; the index lists repos under the base folders as configured
+	real := make(map[string]string, len(repos))
+	for _, r := range repos {
+		real[r] = realPath(r)
+	}
+	rank := func(r string) int {
+		if i, ok := pinned[real[r]]; ok {
+			return i
+		}
+		return len(favorites)
+	}
+	sort.SliceStable(repos, func(a, b int) bool {
+		ra, rb := repos[a], repos[b]
+		if rank(ra) != rank(rb) {
+			return rank(ra) < rank(rb)
+		}
+		if sa, sb := scores[real[ra]], scores[real[rb]]; sa != sb {
+			return sa > sb
+		}
+		return filepath.Base(ra) < filepath.Base(rb)
+	})
+
+	choices := make([]ui.RepoChoice, len(repos))
+	for i, r := range repos {
+		choices[i] = ui.RepoChoice{
+			Path:   r,
+			Name:   filepath.Base(r),
+			Parent: shortPath(filepath.Dir(r)),
+			Alias:  aliases[real[r]],
+			Pinned: rank(r) < len(favorites),
+		}
+	}
+	return choices
+}
+
+// pinOrder maps each pinned repo, with symlinks resolved, to its place in
+// favorite_repos.
+func pinOrder(favorites []string) map[string]int {
+	order := make(map[string]int, len(favorites))
+	for i, f := range favorites {
+		if _, ok := order[realPath(f)]; !ok {
+			order[realPath(f)] = i
+		}
+	}
+	return order
+}
+
+// aliasesByRepo maps each aliased repo, with symlinks resolved, to its alias.
+func aliasesByRepo() map[string]string {
+	aliases := make(map[string]string)
+	for name, dir := range config.RepoAliases() {
+		aliases[realPath(dir)] = name
+	}
+	return aliases
+}
+
+// realPath resolves symlinks in path, so paths through a symlinked folder
+// compare equal to the ones git reports. Falls back to the cleaned path.
+func realPath(path string) string {
+	if real, err := filepath.EvalSymlinks(path); err == nil {
+		return real
+	}
+	return filepath.Clean(path)
+}
+
+// shortPath abbreviates the home folder in path to ~.
+func shortPath(path string) string {
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return path
+	}
+	if path == home {
+		return "~"
+	}
+	if rest, ok := strings.CutPrefix(path, home+string(filepath.Separator)); ok {
+		return "~" + string(filepath.Separator) + rest
+	}
+	return path
+}
+
+// repoByName resolves a --repo value: an alias from repo_aliases, a path
+// inside a repo, or the folder name of a repo in one of the base folders.
+func repoByName(name string) (string, error) {
+	return lookupRepo(name, false)
+}
+
+// lookupRepo does the work of repoByName. With cachedOnly it only consults
+// the repo index as it stands, never scanning the base folders, so it's
+// quick enough for shell completion.
+func lookupRepo(name string, cachedOnly bool) (string, error) {
+	if dir, ok := config.RepoAliases()[name]; ok {
+		if top := git.RepoRoot(dir); top != "" {
+			return top, nil
+		}
+		return "", fmt.Errorf("alias '%s' points to %s, which is not a git repo", name, dir)
+	}
+
+	if info, err := os.Stat(name); err == nil && info.IsDir() {
+		if top := git.RepoRoot(name); top != "" {
+			return top, nil
+		}
+	}
+
+	roots := config.RootPaths()
+	if len(roots) == 0 {
+		return "", fmt.Errorf("no base folder configured")
+	}
+
+	list := scanRepos
+	if cachedOnly {
+		list = cachedRepos
+	}
+	var matches []string
+	for _, r := range list(roots) {
+		if filepath.Base(r) == name {
+			matches = append(matches, r)
+		}
+	}
+	if len(matches) == 0 && !cachedOnly {
+		// It may have been cloned since the index was last refreshed
+		for _, r := range refreshRepos(roots) {
+			if filepath.Base(r) == name {
+				matches = append(matches, r)
+			}
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("no repo named '%s' in %s", name, strings.Join(roots, ", "))
+	case 1:
+		return matches[0], nil
+	default:
+		return "", fmt.Errorf("'%s' matches %d repos — pass a path instead", name, len(matches))
+	}
+}
+
+// findWorktree resolves a worktree given its folder name (my-app-worktree-feature)
+// or its short name (feature). Worktrees of the current repo win over others.
+func findWorktree(roots []string, name string) (string, error) {
+	if repo := git.CurrentRepo(); repo != "" {
+		if main := git.MainWorktreePath(repo); main != "" {
+			candidate := worktreePath(main, name)
+			if info, err := os.Stat(candidate); err == nil && info.IsDir() {
+				return candidate, nil
+			}
+		}
+	}
+
+	var matches []string
+	for _, d := range worktreeDirs(roots) {
+		base := filepath.Base(d)
+		if base == name || strings.HasSuffix(base, "-worktree-"+name) {
+			matches = append(matches, d)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("no worktree named '%s'", name)
+	case 1:
+		return matches[0], nil
+	default:
+		return "", fmt.Errorf("'%s' matches %d worktrees — use the full folder name", name, len(matches))
+	}
+}
+
 // isAbort checks if an error is a user abort (Escape / Ctrl+C).
 func isAbort(err error) bool {
 	return errors.Is(err, huh.ErrUserAborted)
@@ -58,19 +259,127 @@ func handleAbort(err error) {
 	}
 }
 
-// requireDevDir returns the configured base folder, or an empty string with an
-// error message if none is set. Call this before any command that needs DevDir.
-func requireDevDir() string {
-	devDir := config.DevDir()
-	if devDir == "" {
+// runCancellable runs work under a spinner. Ctrl+C cancels the context work
+// is given, so it should stop starting new steps; the ones already running
+// are waited for, so nothing is left half done. Returns whether it was
+// cancelled.
+func runCancellable(title string, work func(ctx context.Context)) bool {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		work(ctx)
+	}()
+	spinner.New().Title(title).Context(ctx).Action(func() { <-done }).Run()
+	if ctx.Err() != nil {
+		ui.Muted("Cancelling — waiting for running git commands to finish...")
+	}
+	<-done
+	return ctx.Err() != nil
+}
+
+// requireRoots returns the configured base folders that exist, printing an
+// error if none are usable. Call this before any command that scans for repos.
+func requireRoots() []string {
+	roots := config.RootPaths()
+	if len(roots) == 0 {
 		ui.Error("No base folder configured. Run 'treework settings' or set DEV_DIR.")
-		return ""
+		return nil
+	}
+
+	var found, missing []string
+	for _, r := range roots {
+		if _, err := os.Stat(r); err != nil {
+			missing = append(missing, r)
+			continue
+		}
+		found = append(found, r)
+	}
+	for _, m := range missing {
+		if len(found) == 0 {
+			ui.Error(fmt.Sprintf("Base folder not found: %s", m))
+		} else {
+			ui.Warn(fmt.Sprintf("Base folder not found, skipping: %s", m))
+		}
+	}
+	return found
+}
+
+// scanRepos lists the git repos across all base folders from the repo
+// index, which is refreshed in the background. Use it where showing results
+// quickly matters more than noticing a repo cloned a moment ago.
+func scanRepos(roots []string) []string {
+	var repos []string
+	for _, r := range roots {
+		repos = append(repos, repoindex.Repos(r)...)
 	}
-	if _, err := os.Stat(devDir); err != nil {
-		ui.Error(fmt.Sprintf("Base folder not found: %s", devDir))
-		return ""
+	return repos
+}
+
+// cachedRepos lists the git repos recorded in the repo index, without
+// scanning or refreshing it.
+func cachedRepos(roots []string) []string {
+	var repos []string
+	for _, r := range roots {
+		repos = append(repos, repoindex.Cached(r)...)
+	}
+	return repos
+}
+
+// refreshRepos brings the repo index up to date and lists the git repos
+// across all base folders. Use it before acting on every repo.
+func refreshRepos(roots []string) []string {
+	var repos []string
+	for _, r := range roots {
+		repos = append(repos, repoindex.Refresh(r)...)
+	}
+	return repos
+}
+
+// worktreeDirs finds worktree folders across all base folders.
+func worktreeDirs(roots []string) []string {
+	seen := make(map[string]bool)
+	var dirs []string
+	for _, r := range roots {
+		for _, d := range git.FindWorktreeDirs(r) {
+			if !seen[d] {
+				seen[d] = true
+				dirs = append(dirs, d)
+			}
+		}
+	}
+	return dirs
+}
+
+// scopedWorktreeDirs lists the worktrees ls and rm offer. Inside a repo, or
+// one of its worktrees, that's the repo's worktrees; otherwise, with all, or
+// if the repo has none, every worktree folder in the base folders. scope is
+// the repo's name when the list is limited to it.
+func scopedWorktreeDirs(roots []string, all bool) (scope string, dirs []string) {
+	if !all {
+		if cur := git.CurrentRepo(); cur != "" {
+			if repo := git.MainWorktreePath(cur); repo != "" {
+				for _, wt := range git.WorktreeList(repo) {
+					if wt.Prunable == "" {
+						dirs = append(dirs, wt.Path)
+					}
+				}
+				if len(dirs) > 0 {
+					return filepath.Base(repo), dirs
+				}
+				ui.Muted(fmt.Sprintf("%s has no worktrees — showing every repo's", filepath.Base(repo)))
+			}
+		}
 	}
-	return devDir
+	return "", worktreeDirs(roots)
+}
+
+// worktreePath computes where a new worktree for repoDir goes, using the
+// layout configured for the repo's base folder.
+func worktreePath(repoDir, name string) string {
+	return git.WorktreePath(repoDir, name, config.LayoutFor(repoDir))
 }
 
 // resolveWorktreePath resolves a worktree path to an absolute path.
@@ -81,3 +390,41 @@ func resolveWorktreePath(path string) string {
 	}
 	return abs
 }
+
+// mergeTarget resolves what merged-branch checks in repoDir compare against,
+// fetching it first if fetch_before_merge_check is on. A failed fetch is
+// reported and the last fetched state is used.
+func mergeTarget(repoDir string) git.MergeTarget {
+	remote, branch, fetch := config.MergeCheckFor(repoDir)
+	target := git.ResolveMergeTarget(repoDir, remote, branch)
+	if fetch {
+		if err := target.Fetch(repoDir); err != nil {
+			ui.Warn(fmt.Sprintf("Couldn't fetch %s, checking merges against the last fetch: %v", target, err))
+		}
+	}
+	return target
+}
+
+// isProtected reports whether a branch must never be deleted, renamed or
+// checked out by new, per protected_branches and the repo's git config.
+func isProtected(repoDir, branch string) bool {
+	return git.IsProtected(repoDir, branch, config.ProtectedBranchesFor(repoDir))
+}
+
+// dirtyDetails converts a worktree's status for ui.WarnDirtyWorktree.
+func dirtyDetails(s git.WorktreeStatus) ui.DirtyDetails {
+	d := ui.DirtyDetails{
+		Changes:    s.ChangeSummary(),
+		Files:      s.Files,
+		Commits:    s.Unpushed,
+		CommitsOn:  s.Upstream,
+		Detached:   s.Branch == "",
+		Operation:  s.Operation,
+		Submodules: s.DirtySubmodules,
+		Stashes:    s.Stashes,
+	}
+	if s.HasUnpushedCommits && len(d.Commits) == 0 {
+		d.Commits = []string{"(git couldn't list them)"}
+	}
+	return d
+}
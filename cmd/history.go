@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/vanderhaka/treework/internal/backup"
+	"github.com/vanderhaka/treework/internal/git"
+	"github.com/vanderhaka/treework/internal/journal"
+	"github.com/vanderhaka/treework/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show recent worktree and branch changes",
+	Long: `Show treework's journal of worktrees created, removed, moved and parked
+and branches deleted or renamed, newest first, with the commits involved.`,
+	Args: cobra.NoArgs,
+	Run:  runHistory,
+}
+
+var undoCmd = &cobra.Command{
+	Use:   "undo",
+	Short: "Restore the most recently removed worktree",
+	Long: `Recreate the most recently removed worktree from the journal: its branch
+is recreated at the recorded commit if it was deleted, the worktree is added
+back at its old path and any backed-up uncommitted work is reapplied.`,
+	Args: cobra.NoArgs,
+	Run:  runUndo,
+}
+
+var flagHistoryLimit int
+
+func init() {
+	historyCmd.Flags().IntVarP(&flagHistoryLimit, "limit", "n", 20, "number of entries to show (0 for all)")
+}
+
+// record appends an operation to the journal. A journal that can't be
+// written is reported but never stops the operation itself.
+func record(e journal.Entry) {
+	if err := journal.Record(e); err != nil {
+		ui.Warn(fmt.Sprintf("Couldn't write to the journal: %v", err))
+	}
+}
+
+func runHistory(cmd *cobra.Command, args []string) {
+	fmt.Println()
+
+	entries := journal.Entries()
+	if len(entries) == 0 {
+		ui.Info("No history yet.")
+		fmt.Println()
+		return
+	}
+	undone := journal.Undone(entries)
+
+	shown := 0
+	for i := len(entries) - 1; i >= 0; i-- {
+		if flagHistoryLimit > 0 && shown == flagHistoryLimit {
+			break
+		}
+		e := entries[i]
+		line := fmt.Sprintf("  %-14s %-13s %s", ago(e.Time), e.Op, describeEntry(e))
+		if undone[e.ID] {
+			line += ui.MutedStyle.Render("  (undone)")
+		}
+		fmt.Println(line)
+		shown++
+	}
+	fmt.Println()
+	ui.Muted("Journal: " + journal.Path())
+	fmt.Println()
+}
+
+// describeEntry summarises what a journal entry touched.
+func describeEntry(e journal.Entry) string {
+	repo := filepath.Base(e.Repo)
+	var what string
+	switch e.Op {
+	case journal.OpMove:
+		what = fmt.Sprintf("%s/%s → %s", repo, filepath.Base(e.From), filepath.Base(e.Path))
+	case journal.OpBranchRename:
+		what = fmt.Sprintf("%s: %s → %s", repo, e.From, e.Branch)
+	case journal.OpBranchDelete:
+		what = fmt.Sprintf("%s: %s", repo, e.Branch)
+	case journal.OpUndo:
+		what = fmt.Sprintf("%s/%s", repo, filepath.Base(e.Path))
+	default:
+		what = fmt.Sprintf("%s/%s", repo, filepath.Base(e.Path))
+		if e.Branch != "" {
+			what += ui.MutedStyle.Render("  (" + e.Branch + ")")
+		}
+	}
+	if e.SHA != "" {
+		what += ui.MutedStyle.Render("  " + short(e.SHA))
+	}
+	if e.Backup != "" {
+		what += ui.MutedStyle.Render("  backed up")
+	}
+	return what
+}
+
+func runUndo(cmd *cobra.Command, args []string) {
+	fmt.Println()
+
+	e, ok := journal.LastUndoable()
+	if !ok {
+		ui.Info("Nothing to undo.")
+		fmt.Println()
+		return
+	}
+
+	ui.Info(fmt.Sprintf("Last removal: %s (%s)", describeEntry(e), ago(e.Time)))
+	if _, err := os.Stat(e.Path); err == nil {
+		ui.Error(fmt.Sprintf("'%s' exists again — nothing to restore", e.Path))
+		os.Exit(1)
+	}
+	if _, err := os.Stat(e.Repo); err != nil {
+		ui.Error(fmt.Sprintf("Repo %s no longer exists", e.Repo))
+		os.Exit(1)
+	}
+
+	ok, err := ui.Confirm(fmt.Sprintf("Recreate %s?", filepath.Base(e.Path)))
+	if err != nil {
+		handleAbort(err)
+	}
+	if !ok {
+		ui.Muted("Cancelled.")
+		fmt.Println()
+		return
+	}
+
+	// Bring the branch back at its recorded commit; a detached worktree is
+	// recreated at the commit itself
+	checkout := e.SHA
+	if e.Branch != "" && e.Branch != "HEAD" {
+		checkout = e.Branch
+		if !git.BranchExists(e.Repo, e.Branch) {
+			if err := git.CreateBranch(e.Repo, e.Branch, e.SHA); err != nil {
+				ui.Error(fmt.Sprintf("Failed to recreate branch '%s': %v", e.Branch, err))
+				os.Exit(1)
+			}
+			ui.Success(fmt.Sprintf("Recreated branch '%s' at %s", e.Branch, short(e.SHA)))
+		} else if tip := git.RevParse(e.Repo, "refs/heads/"+e.Branch); tip != e.SHA {
+			ui.Warn(fmt.Sprintf("Branch '%s' has moved since (now at %s) — using its current tip", e.Branch, short(tip)))
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(e.Path), 0o755); err != nil {
+		ui.Error(err.Error())
+		os.Exit(1)
+	}
+	if err := git.WorktreeAdd(e.Repo, e.Path, checkout, false); err != nil {
+		ui.Error(fmt.Sprintf("Failed to recreate worktree: %v", err))
+		os.Exit(1)
+	}
+
+	if e.Backup != "" {
+		if b, err := backup.Find(backup.List(e.Repo), e.Backup); err != nil {
+			ui.Warn(fmt.Sprintf("Backup %s is gone — uncommitted work can't be restored", e.Backup))
+		} else if err := backup.Apply(b, e.Path); err != nil {
+			ui.Warn(fmt.Sprintf("Couldn't reapply uncommitted work: %v", err))
+		} else if b.HasChanges() {
+			ui.Success("Reapplied uncommitted work from backup " + b.ID)
+		}
+	}
+
+	record(journal.Entry{Op: journal.OpUndo, Repo: e.Repo, Path: e.Path, Branch: e.Branch, SHA: e.SHA, Undoes: e.ID})
+	ui.Success(fmt.Sprintf("Restored %s", filepath.Base(e.Path)))
+	ui.Muted(e.Path)
+	fmt.Println()
+}
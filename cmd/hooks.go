@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/vanderhaka/treework/internal/config"
+	"github.com/vanderhaka/treework/internal/ui"
+)
+
+// runHook runs a configured hook command with sh inside a worktree,
+// streaming its output. The worktree, repo and branch are passed as
+// TREEWORK_* environment variables.
+func runHook(name, command, wtPath, repoDir, branch string) error {
+	c := exec.Command("sh", "-c", command)
+	c.Dir = wtPath
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	c.Env = append(os.Environ(),
+		"TREEWORK_HOOK="+name,
+		"TREEWORK_WORKTREE="+wtPath,
+		"TREEWORK_REPO="+repoDir,
+		"TREEWORK_BRANCH="+branch,
+	)
+	return c.Run()
+}
+
+// runPreRemoveHook runs the pre_remove hook for a worktree, if one is configured.
+// A failing hook is reported but never blocks removal.
+func runPreRemoveHook(wtPath, repoDir, branch string) {
+	hook := config.HooksFor(wtPath).PreRemove
+	if hook == "" {
+		return
+	}
+	if err := runHook("pre_remove", hook, wtPath, repoDir, branch); err != nil {
+		ui.Warn(fmt.Sprintf("pre_remove hook failed: %v", err))
+	}
+}
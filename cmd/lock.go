@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/vanderhaka/treework/internal/git"
+	"github.com/vanderhaka/treework/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var lockCmd = &cobra.Command{
+	Use:   "lock <name>",
+	Short: "Lock a worktree so it isn't removed",
+	Long: `Lock a worktree with git worktree lock. treework rm and clear skip locked
+worktrees unless --force-locked is passed.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeWorktreeNames,
+	Run:               runLock,
+}
+
+var unlockCmd = &cobra.Command{
+	Use:               "unlock <name>",
+	Short:             "Unlock a locked worktree",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeWorktreeNames,
+	Run:               runUnlock,
+}
+
+var (
+	flagLockReason  string
+	flagForceLocked bool
+)
+
+func init() {
+	lockCmd.Flags().StringVar(&flagLockReason, "reason", "", "why the worktree is locked (shown by ls, rm and clear)")
+	for _, c := range []*cobra.Command{rmCmd, clearCmd} {
+		c.Flags().BoolVar(&flagForceLocked, "force-locked", false, "unlock and remove locked worktrees too")
+	}
+}
+
+func runLock(cmd *cobra.Command, args []string) {
+	fmt.Println()
+	wtPath, mainDir := lockTarget(args[0])
+
+	if locked, reason := git.LockStatus(wtPath); locked {
+		ui.Info(fmt.Sprintf("%s is already locked%s", filepath.Base(wtPath), lockSuffix(reason)))
+		return
+	}
+	if err := git.WorktreeLock(mainDir, wtPath, flagLockReason); err != nil {
+		ui.Error(fmt.Sprintf("Failed to lock worktree: %v", err))
+		os.Exit(1)
+	}
+	ui.Success(fmt.Sprintf("Locked %s%s", filepath.Base(wtPath), lockSuffix(flagLockReason)))
+}
+
+func runUnlock(cmd *cobra.Command, args []string) {
+	fmt.Println()
+	wtPath, mainDir := lockTarget(args[0])
+
+	if locked, _ := git.LockStatus(wtPath); !locked {
+		ui.Info(fmt.Sprintf("%s is not locked", filepath.Base(wtPath)))
+		return
+	}
+	if err := git.WorktreeUnlock(mainDir, wtPath); err != nil {
+		ui.Error(fmt.Sprintf("Failed to unlock worktree: %v", err))
+		os.Exit(1)
+	}
+	ui.Success(fmt.Sprintf("Unlocked %s", filepath.Base(wtPath)))
+}
+
+// lockTarget resolves a worktree name for lock/unlock, exiting on failure.
+func lockTarget(name string) (wtPath, mainDir string) {
+	roots := requireRoots()
+	if len(roots) == 0 {
+		os.Exit(1)
+	}
+	wtPath, err := findWorktree(roots, name)
+	if err != nil {
+		ui.Error(err.Error())
+		os.Exit(1)
+	}
+	mainDir = git.MainRepoDir(wtPath)
+	if mainDir == "" {
+		ui.Error("Can't find main repo for this worktree.")
+		os.Exit(1)
+	}
+	return wtPath, mainDir
+}
+
+// lockSuffix formats a lock reason for display after a worktree name.
+func lockSuffix(reason string) string {
+	if reason == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (%s)", reason)
+}
@@ -4,9 +4,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 
-	"github.com/vanderhaka/treework/internal/editor"
-	"github.com/vanderhaka/treework/internal/git"
 	"github.com/vanderhaka/treework/internal/ui"
 	"github.com/spf13/cobra"
 )
@@ -15,11 +15,44 @@ var lsCmd = &cobra.Command{
 	Use:     "ls",
 	Aliases: []string{"list"},
 	Short:   "List worktrees and optionally open one",
-	Run:     runLs,
+	Long: `List worktrees and optionally open one.
+
+Inside a repo, or one of its worktrees, only that repo's worktrees are
+listed and the one you're in is marked; pass --all to see every repo's.
+
+Worktrees are grouped by repo. Each is checked in the background and
+badges fill in as the checks finish: uncommitted files (dirty), commits not
+pushed (↑) or not pulled (↓), merged branches, locks and when it was last
+worked in. Type to filter; tab changes the sort order.
+
+A preview beside the list shows the highlighted worktree's status, recent
+commits, env files and size. Enter offers to open it; ctrl+o opens it
+straight away, ctrl+d asks to remove it and ctrl+y copies its path.`,
+	Args: cobra.NoArgs,
+	Run:  runLs,
+}
+
+var (
+	flagAll    bool   // List every repo's worktrees in ls and rm, even inside a repo
+	flagLsSort string // Initial sort order of the ls list
+)
+
+func init() {
+	for _, c := range []*cobra.Command{lsCmd, rmCmd} {
+		c.Flags().BoolVarP(&flagAll, "all", "a", false, "list worktrees in every repo, not just the current one")
+	}
+	lsCmd.Flags().StringVar(&flagLsSort, "sort", ui.SortRepo, "sort by "+strings.Join(ui.SortOrders, ", "))
+	lsCmd.RegisterFlagCompletionFunc("sort", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return ui.SortOrders, cobra.ShellCompDirectiveNoFileComp
+	})
 }
 
 func runLs(cmd *cobra.Command, args []string) {
 	fmt.Println()
+	if !slices.Contains(ui.SortOrders, flagLsSort) {
+		ui.Error(fmt.Sprintf("--sort must be one of %s", strings.Join(ui.SortOrders, ", ")))
+		os.Exit(1)
+	}
 	doLs(true)
 }
 
@@ -28,33 +61,21 @@ func runLsInteractive(cmd *cobra.Command) {
 }
 
 func doLs(direct bool) {
-	devDir := requireDevDir()
-	if devDir == "" {
+	roots := requireRoots()
+	if len(roots) == 0 {
 		if direct {
 			os.Exit(1)
 		}
 		return
 	}
 
-	dirs := git.FindWorktreeDirs(devDir)
+	scope, dirs := scopedWorktreeDirs(roots, flagAll)
 	if len(dirs) == 0 {
 		ui.Info("No worktrees found.")
 		return
 	}
 
-	var items []ui.WorktreeDisplay
-	for _, d := range dirs {
-		branch := git.CurrentBranch(d)
-		base := filepath.Base(d)
-		repo := extractRepoName(base)
-		items = append(items, ui.WorktreeDisplay{
-			Path:   d,
-			Branch: branch,
-			Repo:   repo,
-		})
-	}
-
-	selected, err := ui.SelectWorktreeDetailed(items)
+	selected, action, err := browseWorktrees(ui.Browser{Title: "Worktrees", Sort: flagLsSort, Select: "select"}, roots, scope, dirs)
 	if err != nil {
 		if isAbort(err) {
 			if direct {
@@ -68,6 +89,14 @@ func doLs(direct bool) {
 		}
 		return
 	}
+	switch action {
+	case ui.ActionOpen:
+		openWorktree(selected, direct)
+		return
+	case ui.ActionRemove:
+		removeFromBrowser(selected, direct)
+		return
+	}
 
 	if selected == ui.BackValue {
 		return
@@ -85,11 +114,7 @@ func doLs(direct bool) {
 	}
 
 	if open {
-		if err := editor.Open(selected); err != nil {
-			ui.Warn(fmt.Sprintf("Could not open editor: %v", err))
-		} else {
-			ui.Success(fmt.Sprintf("Opened: %s", filepath.Base(selected)))
-		}
+		openWorktree(selected, false)
 	} else {
 		ui.Muted(selected)
 	}
@@ -0,0 +1,217 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/vanderhaka/treework/internal/backup"
+	"github.com/vanderhaka/treework/internal/git"
+	"github.com/vanderhaka/treework/internal/journal"
+	"github.com/vanderhaka/treework/internal/sanitize"
+	"github.com/vanderhaka/treework/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var mvCmd = &cobra.Command{
+	Use:     "mv <name> <new-name>",
+	Aliases: []string{"move", "rename"},
+	Short:   "Move or rename a worktree",
+	Long: `Move a worktree to the folder its new name maps to under your layout.
+With --rename-branch the branch is renamed too, and with --rename-upstream
+the branch on the remote is replaced as well.
+
+Backups of the worktree and a tmux session named after its folder follow it
+to the new name. Port settings in its .env files move with the folder
+unchanged, and editor windows open on the old folder aren't moved; reopen
+them with treework open.`,
+	Args: cobra.ExactArgs(2),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return completeWorktreeNames(cmd, args, toComplete)
+	},
+	Run: runMv,
+}
+
+var (
+	flagRenameBranch   bool
+	flagRenameUpstream bool
+)
+
+func init() {
+	mvCmd.Flags().BoolVarP(&flagRenameBranch, "rename-branch", "b", false, "also rename the branch checked out in the worktree")
+	mvCmd.Flags().BoolVar(&flagRenameUpstream, "rename-upstream", false, "also push the renamed branch and delete the old one on the remote (implies --rename-branch)")
+}
+
+func runMv(cmd *cobra.Command, args []string) {
+	fmt.Println()
+
+	roots := requireRoots()
+	if len(roots) == 0 {
+		os.Exit(1)
+	}
+
+	from, err := findWorktree(roots, args[0])
+	if err != nil {
+		ui.Error(err.Error())
+		os.Exit(1)
+	}
+
+	name := sanitize.Name(args[1])
+	if name == "" {
+		ui.Error("New name must contain at least one letter or number.")
+		os.Exit(1)
+	}
+
+	mainDir := git.MainRepoDir(from)
+	if mainDir == "" {
+		ui.Error("Can't find main repo for this worktree.")
+		os.Exit(1)
+	}
+
+	to := worktreePath(mainDir, name)
+	renameBranch := flagRenameBranch || flagRenameUpstream
+	branch := git.CurrentBranch(from)
+
+	// Check everything up front so a refusal never leaves a half-done move
+	if filepath.Clean(to) != filepath.Clean(from) {
+		if _, err := os.Stat(to); err == nil {
+			ui.Error(fmt.Sprintf("'%s' already exists — pick another name or remove it first", to))
+			os.Exit(1)
+		}
+	}
+	if renameBranch {
+		switch {
+		case branch == "" || branch == "HEAD":
+			ui.Error("Worktree is on a detached HEAD — there is no branch to rename.")
+			os.Exit(1)
+		case isProtected(mainDir, branch):
+			ui.Error(fmt.Sprintf("Refusing to rename protected branch '%s'.", branch))
+			os.Exit(1)
+		case branch != name && git.BranchExists(mainDir, name):
+			ui.Error(fmt.Sprintf("Branch '%s' already exists.", name))
+			os.Exit(1)
+		}
+	}
+
+	if filepath.Clean(to) != filepath.Clean(from) {
+		created := missingParent(to)
+		if err := os.MkdirAll(filepath.Dir(to), 0o755); err != nil {
+			ui.Error(fmt.Sprintf("Failed to create %s: %v", filepath.Dir(to), err))
+			os.Exit(1)
+		}
+		if err := git.WorktreeMove(mainDir, from, to); err != nil {
+			removeEmptyParents(filepath.Dir(to), created)
+			ui.Error(fmt.Sprintf("Failed to move worktree: %v", err))
+			os.Exit(1)
+		}
+		ui.Success(fmt.Sprintf("Moved %s → %s", filepath.Base(from), to))
+		record(journal.Entry{Op: journal.OpMove, Repo: mainDir, Path: to, From: from, Branch: branch})
+
+		if n, err := backup.Rename(mainDir, from, to); err != nil {
+			ui.Warn(fmt.Sprintf("Couldn't rename backups of %s: %v", filepath.Base(from), err))
+		} else if n > 0 {
+			ui.Success(fmt.Sprintf("Renamed %d backup(s)", n))
+		}
+	}
+
+	if renameBranch && branch != name {
+		if err := git.RenameBranch(mainDir, branch, name); err != nil {
+			ui.Error(fmt.Sprintf("Failed to rename branch: %v", err))
+			os.Exit(1)
+		}
+		ui.Success(fmt.Sprintf("Renamed branch '%s' → '%s'", branch, name))
+		record(journal.Entry{Op: journal.OpBranchRename, Repo: mainDir, Path: to, From: branch, Branch: name, SHA: git.RevParse(to, "HEAD")})
+		renameUpstream(to, branch, name)
+	}
+
+	if renameTmuxSession(from, to) {
+		ui.Success("Renamed tmux session")
+	}
+	ui.Muted("Editor windows still open on the old folder need reopening: treework open " + name)
+	fmt.Println()
+}
+
+// missingParent returns the topmost folder above path that doesn't exist yet,
+// or "" if path's parent is already there.
+func missingParent(path string) string {
+	missing := ""
+	for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(dir); err == nil {
+			return missing
+		}
+		missing = dir
+		if filepath.Dir(dir) == dir {
+			return missing
+		}
+	}
+}
+
+// removeEmptyParents removes dir and the folders above it up to and including
+// top, as long as they're empty. It undoes a MkdirAll after a failed move.
+func removeEmptyParents(dir, top string) {
+	if top == "" {
+		return
+	}
+	for {
+		if os.Remove(dir) != nil || dir == top {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// renameUpstream deals with the remote branch after a local rename. Without
+// --rename-upstream the branch keeps tracking the old remote name.
+func renameUpstream(wtPath, oldBranch, newBranch string) {
+	remote, remoteBranch := git.Upstream(wtPath, newBranch)
+	if remote == "" {
+		return
+	}
+	if !flagRenameUpstream {
+		ui.Muted(fmt.Sprintf("Still tracks %s/%s — use --rename-upstream to rename it on the remote", remote, remoteBranch))
+		return
+	}
+
+	if err := git.PushBranch(wtPath, remote, newBranch); err != nil {
+		ui.Error(fmt.Sprintf("Failed to push '%s' to %s: %v", newBranch, remote, err))
+		return
+	}
+	ui.Success(fmt.Sprintf("Pushed '%s' to %s", newBranch, remote))
+
+	if remoteBranch != oldBranch {
+		return // Tracked a differently named branch; leave it alone
+	}
+	if err := git.DeleteRemoteBranch(wtPath, remote, oldBranch); err != nil {
+		ui.Warn(fmt.Sprintf("Couldn't delete '%s' on %s: %v", oldBranch, remote, err))
+		return
+	}
+	ui.Success(fmt.Sprintf("Deleted '%s' on %s", oldBranch, remote))
+}
+
+// renameTmuxSession renames a tmux session named after the old worktree
+// folder, if tmux is running and has one. Returns true if a session was renamed.
+func renameTmuxSession(from, to string) bool {
+	if _, err := exec.LookPath("tmux"); err != nil {
+		return false
+	}
+	oldName := tmuxSessionName(filepath.Base(from))
+	newName := tmuxSessionName(filepath.Base(to))
+	if oldName == newName {
+		return false
+	}
+	if exec.Command("tmux", "has-session", "-t", "="+oldName).Run() != nil {
+		return false
+	}
+	return exec.Command("tmux", "rename-session", "-t", "="+oldName, newName).Run() == nil
+}
+
+// tmuxSessionName applies tmux's own substitutions for characters it doesn't
+// allow in session names.
+func tmuxSessionName(s string) string {
+	return strings.NewReplacer(".", "_", ":", "_").Replace(s)
+}
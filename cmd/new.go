@@ -7,20 +7,29 @@ import (
 	"path/filepath"
 
 	"github.com/charmbracelet/huh/spinner"
+	"github.com/vanderhaka/treework/internal/config"
 	"github.com/vanderhaka/treework/internal/deps"
 	"github.com/vanderhaka/treework/internal/editor"
 	"github.com/vanderhaka/treework/internal/env"
 	"github.com/vanderhaka/treework/internal/git"
+	"github.com/vanderhaka/treework/internal/journal"
 	"github.com/vanderhaka/treework/internal/sanitize"
 	"github.com/vanderhaka/treework/internal/ui"
 	"github.com/spf13/cobra"
 )
 
 var newCmd = &cobra.Command{
-	Use:   "new [name]",
-	Short: "Create a new worktree",
-	Args:  cobra.MaximumNArgs(1),
-	Run:   runNew,
+	Use:               "new [name]",
+	Short:             "Create a new worktree",
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeBranches,
+	Run:               runNew,
+}
+
+var flagAllowProtected bool
+
+func init() {
+	newCmd.Flags().BoolVar(&flagAllowProtected, "allow-protected", false, "allow a worktree on a protected branch (see protected_branches)")
 }
 
 func runNewInteractive(cmd *cobra.Command) {
@@ -62,7 +71,7 @@ func doNew(args []string, direct bool) {
 			}
 			return
 		}
-		resolved = resolveWorktreePath(git.WorktreePath(repoDir, name))
+		resolved = resolveWorktreePath(worktreePath(repoDir, name))
 		if _, err := os.Stat(resolved); err == nil {
 			ui.Info(fmt.Sprintf("'%s' already exists — opening it instead.", name))
 			if err := editor.Open(resolved); err != nil {
@@ -70,6 +79,13 @@ func doNew(args []string, direct bool) {
 			}
 			return
 		}
+		if isProtected(repoDir, name) && !flagAllowProtected {
+			ui.Error(fmt.Sprintf("'%s' is a protected branch — pass --allow-protected to check it out in a worktree anyway.", name))
+			if direct {
+				os.Exit(1)
+			}
+			return
+		}
 	} else {
 		for {
 			name, err = ui.InputName()
@@ -93,23 +109,43 @@ func doNew(args []string, direct bool) {
 				continue
 			}
 
-			resolved = resolveWorktreePath(git.WorktreePath(repoDir, name))
+			resolved = resolveWorktreePath(worktreePath(repoDir, name))
 			if _, err := os.Stat(resolved); err == nil {
 				ui.Warn(fmt.Sprintf("'%s' already exists. Pick a different name.", name))
 				continue
 			}
+			if isProtected(repoDir, name) && !flagAllowProtected {
+				ui.Warn(fmt.Sprintf("'%s' is a protected branch. Pick a different name.", name))
+				continue
+			}
 			break
 		}
 	}
 
-	// 3. Create worktree (with spinner)
-	branchExists := git.BranchExists(repoDir, name)
+	// 3. Create worktree (with spinner). A branch that only exists on a remote
+	// is checked out as a new branch tracking it; the remote is named
+	// explicitly, since git refuses if several remotes have the branch.
+	localExists := git.BranchExists(repoDir, name)
+	var remote string
+	if !localExists && git.RemoteBranchExists(repoDir, name) {
+		if remote = git.BranchRemote(repoDir, name); remote == "" {
+			ui.Error(fmt.Sprintf("Branch '%s' is on several remotes and none is the default — set one with: git config treework.remote <remote>", name))
+			if direct {
+				os.Exit(1)
+			}
+			return
+		}
+	}
 	var addErr error
 
 	err = spinner.New().
 		Title(fmt.Sprintf("Creating %s/%s...", repoName, name)).
 		Action(func() {
-			addErr = git.WorktreeAdd(repoDir, resolved, name, !branchExists)
+			if remote != "" {
+				addErr = git.WorktreeAddTracking(repoDir, resolved, name, remote)
+			} else {
+				addErr = git.WorktreeAdd(repoDir, resolved, name, !localExists)
+			}
 		}).
 		Run()
 
@@ -134,14 +170,33 @@ func doNew(args []string, direct bool) {
 		return
 	}
 
-	// 7. Copy .env files
-	copied, _ := env.CopyEnvFiles(repoDir, resolved)
+	record(journal.Entry{Op: journal.OpCreate, Repo: repoDir, Path: resolved, Branch: name, SHA: git.RevParse(resolved, "HEAD")})
+
+	// 7-8. Copy env files, install deps and run the post_create hook
+	setupWorktree(repoDir, resolved, name, direct)
+
+	// 9. Open in editor
+	if err := editor.Open(resolved); err != nil {
+		ui.Warn(fmt.Sprintf("Could not open editor: %v", err))
+	}
+
+	// Print success
+	fmt.Println()
+	ui.Success(fmt.Sprintf("Ready: %s/%s", repoName, name))
+	ui.Muted(resolved)
+}
+
+// setupWorktree gets a freshly checked out worktree ready to work in: copies
+// .env files from the main repo, offers to install dependencies and runs the
+// post_create hook.
+func setupWorktree(repoDir, wtPath, branch string, direct bool) {
+	copied, _ := env.CopyEnvFiles(repoDir, wtPath)
 	if len(copied) > 0 {
 		ui.Muted(fmt.Sprintf("Copied %d env file(s)", len(copied)))
 	}
 
-	// 8. Detect package manager → prompt to install deps
-	if pm := deps.Detect(resolved); pm != nil {
+	// Detect package manager → prompt to install deps
+	if pm := deps.Detect(wtPath); pm != nil {
 		install, err := ui.ConfirmInstall(pm.Name)
 		if err != nil {
 			if isAbort(err) {
@@ -155,7 +210,7 @@ func doNew(args []string, direct bool) {
 			err = spinner.New().
 				Title(fmt.Sprintf("Installing dependencies with %s...", pm.Name)).
 				Action(func() {
-					installErr = deps.Install(resolved, pm)
+					installErr = deps.Install(wtPath, pm)
 				}).
 				Context(context.Background()).
 				Run()
@@ -170,13 +225,11 @@ func doNew(args []string, direct bool) {
 		}
 	}
 
-	// 9. Open in editor
-	if err := editor.Open(resolved); err != nil {
-		ui.Warn(fmt.Sprintf("Could not open editor: %v", err))
+	// Run the post_create hook configured for this repo's base folder
+	if hook := config.HooksFor(repoDir).PostCreate; hook != "" {
+		ui.Muted(fmt.Sprintf("Running post_create hook: %s", hook))
+		if err := runHook("post_create", hook, wtPath, repoDir, branch); err != nil {
+			ui.Warn(fmt.Sprintf("post_create hook failed: %v", err))
+		}
 	}
-
-	// Print success
-	fmt.Println()
-	ui.Success(fmt.Sprintf("Ready: %s/%s", repoName, name))
-	ui.Muted(resolved)
 }
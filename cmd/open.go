@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/vanderhaka/treework/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var openCmd = &cobra.Command{
+	Use:               "open [name]",
+	Short:             "Open a worktree in your editor",
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeWorktreeNames,
+	Run:               runOpen,
+}
+
+func runOpen(cmd *cobra.Command, args []string) {
+	fmt.Println()
+
+	roots := requireRoots()
+	if len(roots) == 0 {
+		os.Exit(1)
+	}
+
+	var selected string
+	if len(args) > 0 {
+		var err error
+		selected, err = findWorktree(roots, args[0])
+		if err != nil {
+			ui.Error(err.Error())
+			os.Exit(1)
+		}
+	} else {
+		dirs := worktreeDirs(roots)
+		if len(dirs) == 0 {
+			ui.Info("No worktrees found.")
+			return
+		}
+
+		var action string
+		var err error
+		selected, action, err = browseWorktrees(ui.Browser{Title: "Open a worktree", Sort: ui.SortRecent, Select: "open"}, roots, "", dirs)
+		if err != nil {
+			handleAbort(err)
+			ui.Error(err.Error())
+			os.Exit(1)
+		}
+		if action == ui.ActionRemove {
+			removeFromBrowser(selected, true)
+			return
+		}
+	}
+
+	openWorktree(selected, true)
+}
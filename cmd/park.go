@@ -0,0 +1,293 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/huh/spinner"
+	"github.com/vanderhaka/treework/internal/git"
+	"github.com/vanderhaka/treework/internal/journal"
+	"github.com/vanderhaka/treework/internal/park"
+	"github.com/vanderhaka/treework/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var parkCmd = &cobra.Command{
+	Use:   "park [name]",
+	Short: "Remove a worktree's folder but keep its branch and unsaved work",
+	Long: `Free disk space (node_modules, build output) without losing a worktree's
+context. Uncommitted and untracked work is saved in a git ref, the worktree's
+env files and notes are recorded, and the folder is removed. The branch stays.
+
+Bring it back with 'treework unpark <name>'.`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeWorktreeNames,
+	Run:               runPark,
+}
+
+var unparkCmd = &cobra.Command{
+	Use:   "unpark [name]",
+	Short: "Recreate a parked worktree",
+	Long: `Recreate a parked worktree at its old path, reapply its unsaved work and
+env files, copy env files from the main repo and offer to install dependencies.`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeParked,
+	Run:               runUnpark,
+}
+
+var (
+	flagParkNote string
+	flagParkList bool
+)
+
+func init() {
+	parkCmd.Flags().StringVarP(&flagParkNote, "note", "m", "", "note to show when unparking")
+	parkCmd.Flags().BoolVar(&flagParkList, "list", false, "list parked worktrees")
+}
+
+func runPark(cmd *cobra.Command, args []string) {
+	fmt.Println()
+	if flagParkList {
+		listParked()
+		return
+	}
+
+	roots := requireRoots()
+	if len(roots) == 0 {
+		os.Exit(1)
+	}
+
+	var selected string
+	if len(args) > 0 {
+		var err error
+		selected, err = findWorktree(roots, args[0])
+		if err != nil {
+			ui.Error(err.Error())
+			os.Exit(1)
+		}
+	} else {
+		dirs := worktreeDirs(roots)
+		if len(dirs) == 0 {
+			ui.Info("No worktrees found.")
+			return
+		}
+		var err error
+		selected, err = ui.SelectWorktree(dirs)
+		if err != nil {
+			handleAbort(err)
+			ui.Error(err.Error())
+			os.Exit(1)
+		}
+		if selected == ui.BackValue {
+			return
+		}
+	}
+
+	mainDir := git.MainRepoDir(selected)
+	if mainDir == "" {
+		ui.Error("Can't find main repo for this worktree.")
+		os.Exit(1)
+	}
+	if locked, reason := git.LockStatus(selected); locked {
+		ui.Error(fmt.Sprintf("%s is locked%s — unlock it first", filepath.Base(selected), lockSuffix(reason)))
+		os.Exit(1)
+	}
+	branch := git.CurrentBranch(selected)
+
+	p, err := park.Park(mainDir, selected, branch, flagParkNote)
+	if err != nil {
+		ui.Error(fmt.Sprintf("Can't park %s: %v", filepath.Base(selected), err))
+		os.Exit(1)
+	}
+
+	runPreRemoveHook(selected, mainDir, branch)
+
+	// The work is saved, so the folder can go even if it's dirty
+	var removeErr error
+	err = spinner.New().
+		Title("Removing worktree folder...").
+		Action(func() {
+			removeErr = git.WorktreeForceRemove(mainDir, selected)
+			git.WorktreePrune(mainDir)
+		}).
+		Run()
+	if err != nil {
+		handleAbort(err)
+	}
+	if removeErr != nil {
+		park.Forget(*p)
+		ui.Error(fmt.Sprintf("Failed to remove worktree: %v", removeErr))
+		os.Exit(1)
+	}
+
+	ui.Success(fmt.Sprintf("Parked %s — branch '%s' kept", p.Name, p.Branch))
+	record(journal.Entry{Op: journal.OpPark, Repo: mainDir, Path: selected, Branch: p.Branch, SHA: p.Head})
+	if p.HasChanges() {
+		ui.Muted("Uncommitted work saved")
+	}
+	ui.Muted("Bring it back with: treework unpark " + p.Name)
+	fmt.Println()
+}
+
+func runUnpark(cmd *cobra.Command, args []string) {
+	fmt.Println()
+
+	parked := park.List()
+	if len(parked) == 0 {
+		ui.Info("No parked worktrees.")
+		fmt.Println()
+		return
+	}
+
+	var p park.Parked
+	if len(args) > 0 {
+		var err error
+		p, err = findParked(parked, args[0])
+		if err != nil {
+			ui.Error(err.Error())
+			os.Exit(1)
+		}
+	} else {
+		var items []ui.WorktreeDisplay
+		for _, p := range parked {
+			items = append(items, ui.WorktreeDisplay{Path: p.Path, Branch: p.Branch, Repo: p.Repo})
+		}
+		selected, err := ui.SelectWorktreeDetailed(items)
+		if err != nil {
+			handleAbort(err)
+			ui.Error(err.Error())
+			os.Exit(1)
+		}
+		if selected == ui.BackValue {
+			return
+		}
+		for _, c := range parked {
+			if c.Path == selected {
+				p = c
+			}
+		}
+	}
+
+	if _, err := os.Stat(p.Path); err == nil {
+		ui.Error(fmt.Sprintf("'%s' already exists — move it out of the way first", p.Path))
+		os.Exit(1)
+	}
+
+	// The branch is kept while parked, but may have been deleted by hand since
+	if !git.BranchExists(p.RepoDir, p.Branch) {
+		if err := git.CreateBranch(p.RepoDir, p.Branch, p.Head); err != nil {
+			ui.Error(fmt.Sprintf("Failed to recreate branch '%s': %v", p.Branch, err))
+			os.Exit(1)
+		}
+		ui.Muted(fmt.Sprintf("Recreated branch '%s' at %s", p.Branch, short(p.Head)))
+	}
+
+	if err := os.MkdirAll(filepath.Dir(p.Path), 0o755); err != nil {
+		ui.Error(err.Error())
+		os.Exit(1)
+	}
+	var addErr error
+	err := spinner.New().
+		Title(fmt.Sprintf("Recreating %s...", p.Name)).
+		Action(func() {
+			addErr = git.WorktreeAdd(p.RepoDir, p.Path, p.Branch, false)
+		}).
+		Run()
+	if err != nil {
+		handleAbort(err)
+	}
+	if addErr != nil {
+		ui.Error(fmt.Sprintf("Failed to create worktree: %v", addErr))
+		os.Exit(1)
+	}
+
+	record(journal.Entry{Op: journal.OpUnpark, Repo: p.RepoDir, Path: p.Path, Branch: p.Branch, SHA: p.Head})
+
+	restored := true
+	if err := park.Restore(p); err != nil {
+		ui.Warn(err.Error())
+		restored = false
+	} else if p.HasChanges() {
+		ui.Success("Reapplied uncommitted work")
+	}
+
+	setupWorktree(p.RepoDir, p.Path, p.Branch, true)
+
+	if restored {
+		if err := park.Forget(p); err != nil {
+			ui.Warn(fmt.Sprintf("Couldn't clear the parked record: %v", err))
+		}
+	}
+
+	fmt.Println()
+	ui.Success(fmt.Sprintf("Unparked %s (branch: %s)", p.Name, p.Branch))
+	printParkDetails(p)
+	ui.Muted(p.Path)
+	fmt.Println()
+}
+
+// listParked prints every parked worktree.
+func listParked() {
+	parked := park.List()
+	if len(parked) == 0 {
+		ui.Info("No parked worktrees.")
+		fmt.Println()
+		return
+	}
+	for _, p := range parked {
+		fmt.Printf("  %s  %s\n", p.Name, ui.MutedStyle.Render(fmt.Sprintf("(%s) · parked %s", p.Branch, ago(p.ParkedAt))))
+		printParkDetails(p)
+	}
+	fmt.Println()
+}
+
+// printParkDetails shows the notes and ports recorded for a parked worktree.
+func printParkDetails(p park.Parked) {
+	if p.Notes != "" {
+		ui.Muted("    note: " + p.Notes)
+	}
+	if len(p.Ports) > 0 {
+		var ports []string
+		for k, v := range p.Ports {
+			ports = append(ports, k+"="+v)
+		}
+		sort.Strings(ports)
+		ui.Muted("    ports: " + strings.Join(ports, " "))
+	}
+	if p.BaseBranch != "" && p.Base != "" {
+		ui.Muted(fmt.Sprintf("    based on %s at %s", p.BaseBranch, short(p.Base)))
+	}
+}
+
+// findParked matches a parked worktree by folder name, short name or branch.
+func findParked(parked []park.Parked, name string) (park.Parked, error) {
+	var matches []park.Parked
+	for _, p := range parked {
+		if p.Name == name || strings.HasSuffix(p.Name, "-worktree-"+name) || p.Branch == name {
+			matches = append(matches, p)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return park.Parked{}, fmt.Errorf("no parked worktree named '%s' — see 'treework park --list'", name)
+	case 1:
+		return matches[0], nil
+	}
+
+	// Same name parked in several repos: prefer the current one
+	if repo := git.CurrentRepo(); repo != "" {
+		for _, p := range matches {
+			if p.RepoDir == repo {
+				return p, nil
+			}
+		}
+	}
+	var where []string
+	for _, p := range matches {
+		where = append(where, p.Path)
+	}
+	return park.Parked{}, fmt.Errorf("'%s' is parked in several repos: %s", name, strings.Join(where, ", "))
+}
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/vanderhaka/treework/internal/procs"
+	"github.com/vanderhaka/treework/internal/ui"
+)
+
+// stopTimeout is how long processes get to exit after SIGTERM before SIGKILL.
+const stopTimeout = 5 * time.Second
+
+// inCurrentDir reports whether the shell treework was run from is inside
+// wtPath. Removing it would leave the shell in a deleted folder.
+func inCurrentDir(wtPath string) bool {
+	cwd, err := os.Getwd()
+	return err == nil && procs.Contains(wtPath, cwd)
+}
+
+// stopProcessesIn looks for processes running in or holding files open in
+// the worktrees, lists them and offers to stop them. Returns the worktrees
+// that still have processes running, because the user declined or they
+// wouldn't exit; those shouldn't be removed. If the user can't be asked,
+// every worktree with processes is returned along with the error.
+func stopProcessesIn(paths []string) ([]string, error) {
+	using := make(map[string][]procs.Process)
+	var all []procs.Process
+	for _, p := range paths {
+		if found := procs.InDir(p); len(found) > 0 {
+			using[p] = found
+			all = append(all, found...)
+		}
+	}
+	if len(all) == 0 {
+		return nil, nil
+	}
+
+	fmt.Println()
+	ui.Warn(fmt.Sprintf("%d process(es) are still using %s:", len(all), worktreeNames(using)))
+	for _, p := range paths {
+		for _, proc := range using[p] {
+			cmd := proc.Command
+			if cmd == "" {
+				cmd = proc.Name
+			}
+			if len(cmd) > 60 {
+				cmd = cmd[:57] + "..."
+			}
+			ui.Muted(fmt.Sprintf("  • %d %s — %s", proc.PID, cmd, proc.Reason))
+		}
+	}
+	fmt.Println()
+
+	ok, err := ui.Confirm(fmt.Sprintf("Stop them? (SIGTERM, then SIGKILL after %s)", stopTimeout))
+	if err != nil {
+		return keys(using), err
+	}
+	if !ok {
+		return keys(using), nil
+	}
+
+	left := procs.Terminate(all, stopTimeout)
+	if len(left) == 0 {
+		ui.Success(fmt.Sprintf("Stopped %d process(es)", len(all)))
+		return nil, nil
+	}
+	ui.Warn(fmt.Sprintf("%d process(es) wouldn't stop", len(left)))
+	var busy []string
+	for p, found := range using {
+		for _, proc := range found {
+			if containsPID(left, proc.PID) {
+				busy = append(busy, p)
+				break
+			}
+		}
+	}
+	return busy, nil
+}
+
+// worktreeNames describes the worktrees in a processes map for a message.
+func worktreeNames(using map[string][]procs.Process) string {
+	if len(using) == 1 {
+		for p := range using {
+			return filepath.Base(p)
+		}
+	}
+	return fmt.Sprintf("%d worktrees", len(using))
+}
+
+func keys(m map[string][]procs.Process) []string {
+	var out []string
+	for k := range m {
+		out = append(out, k)
+	}
+	return out
+}
+
+func containsPID(list []procs.Process, pid int) bool {
+	for _, p := range list {
+		if p.PID == pid {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,284 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/huh/spinner"
+	"github.com/vanderhaka/treework/internal/config"
+	"github.com/vanderhaka/treework/internal/git"
+	"github.com/vanderhaka/treework/internal/repoindex"
+	"github.com/vanderhaka/treework/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var reposCmd = &cobra.Command{
+	Use:   "repos",
+	Short: "List the repos in your base folders",
+	Long: `List the git repos treework knows about in your base folders.
+
+The list comes from an index kept in your cache folder, so pickers show up
+straight away. It's brought up to date in the background as you use
+treework, re-reading only folders that have changed; run 'treework repos
+refresh' to rescan everything now.
+
+Repos you pin are listed first in the project picker, followed by the ones
+you've used most often and most recently. Aliases are short names you can
+pass to --repo.`,
+	Args: cobra.NoArgs,
+	Run:  runRepos,
+}
+
+var reposRefreshCmd = &cobra.Command{
+	Use:   "refresh",
+	Short: "Rescan the base folders for repos",
+	Args:  cobra.NoArgs,
+	Run:   runReposRefresh,
+}
+
+var reposPinCmd = &cobra.Command{
+	Use:   "pin [repo]",
+	Short: "Pin a repo to the top of the project picker",
+	Long: `Pin a repo to the top of the project picker. Pinned repos are listed in
+the order they were pinned. With no argument, pins the current repo.`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeRepoNames,
+	Run:               func(cmd *cobra.Command, args []string) { runReposPin(args, true) },
+}
+
+var reposUnpinCmd = &cobra.Command{
+	Use:               "unpin [repo]",
+	Short:             "Unpin a repo from the project picker",
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeRepoNames,
+	Run:               func(cmd *cobra.Command, args []string) { runReposPin(args, false) },
+}
+
+var reposAliasCmd = &cobra.Command{
+	Use:   "alias <name> [repo]",
+	Short: "Give a repo a short name for --repo",
+	Long: `Give a repo a short name to pass to --repo, e.g.
+
+  treework repos alias api ~/work/api-server
+  treework new --repo api fix-x
+
+With no repo, the alias points to the current repo.`,
+	Args: cobra.RangeArgs(1, 2),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 1 {
+			return completeRepoNames(cmd, args, toComplete)
+		}
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	},
+	Run: runReposAlias,
+}
+
+var reposUnaliasCmd = &cobra.Command{
+	Use:   "unalias <name>",
+	Short: "Remove a repo alias",
+	Args:  cobra.ExactArgs(1),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		var names []string
+		for name, dir := range config.RepoAliases() {
+			names = append(names, name+"\t"+dir)
+		}
+		return names, cobra.ShellCompDirectiveNoFileComp
+	},
+	Run: runReposUnalias,
+}
+
+func init() {
+	reposCmd.AddCommand(reposRefreshCmd)
+	reposCmd.AddCommand(reposPinCmd)
+	reposCmd.AddCommand(reposUnpinCmd)
+	reposCmd.AddCommand(reposAliasCmd)
+	reposCmd.AddCommand(reposUnaliasCmd)
+}
+
+func runRepos(cmd *cobra.Command, args []string) {
+	fmt.Println()
+	roots := requireRoots()
+	if len(roots) == 0 {
+		os.Exit(1)
+	}
+
+	pinned := pinOrder(config.FavoriteRepos())
+	aliases := aliasesByRepo()
+	for _, root := range roots {
+		repos := repoindex.Repos(root)
+		ui.Info(fmt.Sprintf("%s %s", ui.BoldStyle.Render(root), ui.MutedStyle.Render(scannedAgo(root))))
+		if len(repos) == 0 {
+			ui.Muted("No repos found")
+		}
+		for _, r := range repos {
+			line := ui.MutedStyle.Render("      • " + r)
+			real := realPath(r)
+			if _, ok := pinned[real]; ok {
+				line += ui.WarnStyle.Render("  ★ pinned")
+			}
+			if name := aliases[real]; name != "" {
+				line += ui.InfoStyle.Render("  alias " + name)
+			}
+			fmt.Println(line)
+		}
+		fmt.Println()
+	}
+}
+
+// repoArg resolves a repo given on the command line, or the current repo.
+func repoArg(args []string) string {
+	var repo string
+	var err error
+	if len(args) > 0 {
+		repo, err = repoByName(args[0])
+	} else if repo = git.CurrentRepo(); repo == "" {
+		err = fmt.Errorf("not inside a git repo — pass a repo name or path")
+	}
+	if err != nil {
+		ui.Error(err.Error())
+		fmt.Println()
+		os.Exit(1)
+	}
+	return repo
+}
+
+func runReposPin(args []string, pin bool) {
+	fmt.Println()
+	repo := repoArg(args)
+	favorites := slices.Clone(config.FavoriteRepos())
+
+	i := slices.IndexFunc(favorites, func(f string) bool { return realPath(f) == realPath(repo) })
+	switch {
+	case pin && i >= 0:
+		ui.Muted(repo + " is already pinned")
+		fmt.Println()
+		return
+	case !pin && i < 0:
+		ui.Muted(repo + " isn't pinned")
+		fmt.Println()
+		return
+	case pin:
+		favorites = append(favorites, repo)
+	default:
+		favorites = slices.Delete(favorites, i, i+1)
+	}
+
+	scope := config.Scope{Profile: config.ActiveProfile()}
+	var err error
+	if len(favorites) == 0 {
+		err = config.Unset("favorite_repos", scope)
+	} else {
+		_, err = config.Set("favorite_repos", strings.Join(favorites, ","), scope)
+	}
+	if err != nil {
+		ui.Error(err.Error())
+		os.Exit(1)
+	}
+	if pin {
+		ui.Success("Pinned " + repo)
+	} else {
+		ui.Success("Unpinned " + repo)
+	}
+	fmt.Println()
+}
+
+func runReposAlias(cmd *cobra.Command, args []string) {
+	fmt.Println()
+	name := args[0]
+	if err := config.ValidateAlias(name); err != nil {
+		ui.Error(err.Error())
+		os.Exit(1)
+	}
+	repo := repoArg(args[1:])
+
+	aliases := make(map[string]string)
+	for n, dir := range config.RepoAliases() {
+		aliases[n] = dir
+	}
+	aliases[name] = repo
+	if _, err := config.Set("repo_aliases", aliasList(aliases), config.Scope{Profile: config.ActiveProfile()}); err != nil {
+		ui.Error(err.Error())
+		os.Exit(1)
+	}
+	ui.Success(fmt.Sprintf("'%s' now means %s", name, repo))
+	fmt.Println()
+}
+
+func runReposUnalias(cmd *cobra.Command, args []string) {
+	fmt.Println()
+	name := args[0]
+	aliases := make(map[string]string)
+	for n, dir := range config.RepoAliases() {
+		aliases[n] = dir
+	}
+	if _, ok := aliases[name]; !ok {
+		ui.Error(fmt.Sprintf("no alias named '%s'", name))
+		os.Exit(1)
+	}
+	delete(aliases, name)
+
+	scope := config.Scope{Profile: config.ActiveProfile()}
+	var err error
+	if len(aliases) == 0 {
+		err = config.Unset("repo_aliases", scope)
+	} else {
+		_, err = config.Set("repo_aliases", aliasList(aliases), scope)
+	}
+	if err != nil {
+		ui.Error(err.Error())
+		os.Exit(1)
+	}
+	ui.Success(fmt.Sprintf("Removed alias '%s'", name))
+	fmt.Println()
+}
+
+// aliasList formats aliases as the name=path list repo_aliases takes.
+func aliasList(aliases map[string]string) string {
+	var pairs []string
+	for name, dir := range aliases {
+		pairs = append(pairs, name+"="+dir)
+	}
+	return strings.Join(pairs, ",")
+}
+
+func runReposRefresh(cmd *cobra.Command, args []string) {
+	fmt.Println()
+	roots := requireRoots()
+	if len(roots) == 0 {
+		os.Exit(1)
+	}
+
+	counts := make([]int, len(roots))
+	start := time.Now()
+	err := spinner.New().
+		Title("Scanning base folders...").
+		Action(func() {
+			for i, root := range roots {
+				counts[i] = len(repoindex.Rescan(root))
+			}
+		}).
+		Run()
+	if err != nil {
+		handleAbort(err)
+		ui.Error(err.Error())
+		os.Exit(1)
+	}
+
+	for i, root := range roots {
+		ui.Success(fmt.Sprintf("%s — %s", root, plural(counts[i], "repo")))
+	}
+	ui.Muted(fmt.Sprintf("Scanned in %s", time.Since(start).Round(time.Millisecond)))
+	fmt.Println()
+}
+
+// scannedAgo describes when a base folder was last scanned.
+func scannedAgo(root string) string {
+	t := repoindex.Scanned(root)
+	if t.IsZero() {
+		return "(not scanned yet)"
+	}
+	return "(scanned " + ago(t) + ")"
+}
@@ -7,64 +7,112 @@ import (
 
 	"github.com/charmbracelet/huh/spinner"
 	"github.com/vanderhaka/treework/internal/git"
+	"github.com/vanderhaka/treework/internal/journal"
 	"github.com/vanderhaka/treework/internal/ui"
 	"github.com/spf13/cobra"
 )
 
 var rmCmd = &cobra.Command{
-	Use:     "rm",
+	Use:     "rm [name]",
 	Aliases: []string{"remove"},
 	Short:   "Remove a worktree",
-	Run:     runRm,
+	Long: `Remove a worktree, after checking it for unsaved work.
+
+Without a name you pick one from a list. Inside a repo, or one of its
+worktrees, the list holds only that repo's worktrees, with the one you're in
+marked; pass --all to see every repo's.`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeWorktreeNames,
+	Run:               runRm,
 }
 
 func runRm(cmd *cobra.Command, args []string) {
 	fmt.Println()
-	doRm(true)
+	doRm(args, true)
 }
 
 func runRmInteractive(cmd *cobra.Command) {
-	doRm(false)
+	doRm(nil, false)
 }
 
-func doRm(direct bool) {
-	devDir := requireDevDir()
-	if devDir == "" {
+func doRm(args []string, direct bool) {
+	roots := requireRoots()
+	if len(roots) == 0 {
 		if direct {
 			os.Exit(1)
 		}
 		return
 	}
 
-	dirs := git.FindWorktreeDirs(devDir)
-	if len(dirs) == 0 {
-		ui.Info("No worktrees found.")
-		return
-	}
+	var selected string
+	if len(args) > 0 {
+		var err error
+		selected, err = findWorktree(roots, args[0])
+		if err != nil {
+			ui.Error(err.Error())
+			if direct {
+				os.Exit(1)
+			}
+			return
+		}
+	} else {
+		scope, dirs := scopedWorktreeDirs(roots, flagAll)
+		if len(dirs) == 0 {
+			ui.Info("No worktrees found.")
+			return
+		}
 
-	selected, err := ui.SelectWorktree(dirs)
-	if err != nil {
-		if isAbort(err) {
+		var action string
+		var err error
+		selected, action, err = browseWorktrees(ui.Browser{Title: "Remove a worktree", Sort: ui.SortRepo, Select: "remove"}, roots, scope, dirs)
+		if err != nil {
+			if isAbort(err) {
+				if direct {
+					handleAbort(err)
+				}
+				return
+			}
+			ui.Error(err.Error())
 			if direct {
-				handleAbort(err)
+				os.Exit(1)
 			}
 			return
 		}
-		ui.Error(err.Error())
+		if action == ui.ActionOpen {
+			openWorktree(selected, direct)
+			return
+		}
+	}
+
+	removeOne(selected, direct)
+}
+
+// removeOne removes a worktree after checking it for unsaved work and
+// running processes, then deletes its branch if it's merged or the user
+// agrees.
+func removeOne(selected string, direct bool) {
+	branch := git.CurrentBranch(selected)
+	mainDir := git.MainWorktreePath(selected)
+	if mainDir == "" {
+		ui.Error("Can't find main repo for this worktree.")
 		if direct {
 			os.Exit(1)
 		}
 		return
 	}
 
-	if selected == ui.BackValue {
+	locked, lockReason := git.LockStatus(selected)
+	if locked && !flagForceLocked {
+		ui.Warn(fmt.Sprintf("%s is locked%s", filepath.Base(selected), lockSuffix(lockReason)))
+		ui.Muted("Run 'treework unlock' first, or pass --force-locked")
+		if direct {
+			os.Exit(1)
+		}
 		return
 	}
 
-	branch := git.CurrentBranch(selected)
-	mainDir := git.MainWorktreePath(selected)
-	if mainDir == "" {
-		ui.Error("Can't find main repo for this worktree.")
+	if inCurrentDir(selected) {
+		ui.Error(fmt.Sprintf("You're inside %s — cd out of it first.", filepath.Base(selected)))
 		if direct {
 			os.Exit(1)
 		}
@@ -78,7 +126,7 @@ func doRm(direct bool) {
 	forceNeeded := status.IsDirty()
 
 	if forceNeeded {
-		ui.WarnDirtyWorktree(status.HasUncommittedChanges, status.HasUnpushedCommits)
+		ui.WarnDirtyWorktree(dirtyDetails(status))
 
 		confirmed, confirmErr := ui.ConfirmDirtyRemove()
 		if confirmErr != nil {
@@ -95,10 +143,52 @@ func doRm(direct bool) {
 		}
 	}
 
+	// Processes still running inside would be left in a deleted folder
+	busy, stopErr := stopProcessesIn([]string{selected})
+	if stopErr != nil {
+		if direct {
+			handleAbort(stopErr)
+		}
+		if !isAbort(stopErr) {
+			ui.Error(stopErr.Error())
+			ui.Muted("Kept worktree — no changes made")
+			if direct {
+				os.Exit(1)
+			}
+		}
+		return
+	}
+	if len(busy) > 0 {
+		ui.Muted("Kept worktree — no changes made")
+		return
+	}
+
+	// Snapshot unsaved work so it can be restored with 'treework backups restore'
+	backupID := ""
+	if forceNeeded && !flagNoBackup {
+		id, ok := backupBeforeRemove(mainDir, selected, branch)
+		if !ok {
+			ui.Muted("Nothing was removed. Pass --no-backup to remove without a backup.")
+			if direct {
+				os.Exit(1)
+			}
+			return
+		}
+		backupID = id
+	}
+	head := git.RevParse(selected, "HEAD")
+
+	runPreRemoveHook(selected, mainDir, branch)
+
 	var removeErr error
-	err = spinner.New().
+	err := spinner.New().
 		Title("Removing worktree...").
 		Action(func() {
+			if locked {
+				if removeErr = git.WorktreeUnlock(mainDir, selected); removeErr != nil {
+					return
+				}
+			}
 			if forceNeeded {
 				removeErr = git.WorktreeForceRemove(mainDir, selected)
 			} else {
@@ -131,14 +221,19 @@ func doRm(direct bool) {
 	}
 
 	ui.Success("Removed worktree")
+	record(journal.Entry{Op: journal.OpRemove, Repo: mainDir, Path: selected, Branch: branch, SHA: head, Backup: backupID})
 
-	if branch != "" && branch != "HEAD" && branch != "main" && branch != "master" {
-		if git.IsBranchMerged(mainDir, branch) {
-			if err := git.DeleteBranch(mainDir, branch); err == nil {
-				ui.Success(fmt.Sprintf("Deleted merged branch '%s'", branch))
+	if isProtected(mainDir, branch) {
+		ui.Muted(fmt.Sprintf("Kept protected branch '%s'", branch))
+	} else if branch != "" && branch != "HEAD" {
+		merge := git.MergeStatusAgainst(mainDir, branch, mergeTarget(mainDir))
+		if merge.Merged() {
+			if err := git.DeleteMergedBranch(mainDir, branch, head, merge); err == nil {
+				ui.Success(fmt.Sprintf("Deleted branch '%s' (%s)", branch, merge.Describe()))
+				record(journal.Entry{Op: journal.OpBranchDelete, Repo: mainDir, Branch: branch, SHA: head})
 			}
 		} else {
-			ui.Warn(fmt.Sprintf("Branch '%s' is not merged", branch))
+			ui.Warn(fmt.Sprintf("Branch '%s' is %s", branch, merge.Describe()))
 			forceDelete, err := ui.ConfirmForceDelete(branch)
 			if err != nil {
 				if isAbort(err) {
@@ -148,9 +243,18 @@ func doRm(direct bool) {
 					return
 				}
 			}
+			if forceDelete && backupID == "" && !flagNoBackup {
+				id, ok := backupBeforeRemove(mainDir, selected, branch)
+				if !ok {
+					ui.Muted(fmt.Sprintf("Kept branch '%s'", branch))
+					return
+				}
+				backupID = id
+			}
 			if forceDelete {
 				if err := git.ForceDeleteBranch(mainDir, branch); err == nil {
 					ui.Success(fmt.Sprintf("Force deleted branch '%s'", branch))
+					record(journal.Entry{Op: journal.OpBranchDelete, Repo: mainDir, Branch: branch, SHA: head, Backup: backupID})
 				} else {
 					ui.Error(fmt.Sprintf("Failed to delete branch '%s'", branch))
 				}
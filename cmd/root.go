@@ -15,16 +15,46 @@ var rootCmd = &cobra.Command{
 	Short: "Git worktree manager",
 	Long:  ui.Banner(),
 	Run:   runRoot,
+
+	PersistentPreRun: checkConfig,
 }
 
+// flagProfile holds the global --profile flag.
+var flagProfile string
+
 func init() {
 	rootCmd.AddCommand(newCmd)
 	rootCmd.AddCommand(lsCmd)
 	rootCmd.AddCommand(rmCmd)
+	rootCmd.AddCommand(mvCmd)
+	rootCmd.AddCommand(lockCmd)
+	rootCmd.AddCommand(unlockCmd)
+	rootCmd.AddCommand(backupsCmd)
+	rootCmd.AddCommand(parkCmd)
+	rootCmd.AddCommand(unparkCmd)
+	rootCmd.AddCommand(historyCmd)
+	rootCmd.AddCommand(undoCmd)
 	rootCmd.AddCommand(clearCmd)
+	rootCmd.AddCommand(gcCmd)
+	rootCmd.AddCommand(doctorCmd)
+	rootCmd.AddCommand(reposCmd)
+	rootCmd.AddCommand(openCmd)
+	rootCmd.AddCommand(cdCmd)
+	rootCmd.AddCommand(configCmd)
+	rootCmd.AddCommand(settingsCmd)
+	rootCmd.AddCommand(completionCmd)
 	rootCmd.AddCommand(versionCmd)
 
+	for _, c := range []*cobra.Command{newCmd, clearCmd, gcCmd} {
+		c.Flags().StringVar(&flagRepo, "repo", "", "repo folder name or path (default: current repo)")
+		c.RegisterFlagCompletionFunc("repo", completeRepoNames)
+	}
+
+	// Replaced by our own completion command (bash, zsh, fish)
 	rootCmd.CompletionOptions.DisableDefaultCmd = true
+
+	rootCmd.PersistentFlags().StringVar(&flagProfile, "profile", "", "settings profile to use (default: $TREEWORK_PROFILE)")
+	rootCmd.RegisterFlagCompletionFunc("profile", completeProfiles)
 }
 
 func Execute() {
@@ -37,12 +67,40 @@ func Execute() {
 	}
 }
 
+// checkConfig selects the --profile and stops with a readable message when
+// the config file is broken, instead of quietly running with defaults.
+// Commands that don't read settings (or that help fix them) are let through.
+func checkConfig(cmd *cobra.Command, args []string) {
+	config.UseProfile(flagProfile)
+
+	switch cmd.Name() {
+	case "completion", "version", "help", "path", cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd:
+		return
+	}
+	if _, err := config.Load(); err != nil {
+		ui.Error(err.Error())
+		ui.Muted(fmt.Sprintf("Fix or remove %s, then try again.", config.Path()))
+		os.Exit(1)
+	}
+	if err := config.Migrate(); err != nil {
+		ui.Warn(fmt.Sprintf("Couldn't upgrade the config file: %v", err))
+	}
+
+	// 'config' commands may create the profile, so don't insist it exists yet
+	if cmd.Parent() != configCmd {
+		if err := config.CheckProfile(); err != nil {
+			ui.Error(err.Error())
+			os.Exit(1)
+		}
+	}
+}
+
 func runRoot(cmd *cobra.Command, args []string) {
 	fmt.Println()
 	fmt.Println(ui.Banner())
 
 	// First-run setup: prompt for base folder if not configured
-	if config.DevDir() == "" {
+	if len(config.RootPaths()) == 0 {
 		fmt.Println()
 		ui.Info("Welcome! Let's set your base folder (where your git repos live).")
 		fmt.Println()
@@ -54,29 +112,23 @@ func runRoot(cmd *cobra.Command, args []string) {
 			// They'll be prompted again next time or can use 'treework settings'
 			fmt.Println()
 			ui.Muted("Skipped — you can set it later with 'treework settings' or set DEV_DIR.")
+		} else if stored, setErr := config.Set("roots", selected, config.Scope{Profile: config.ActiveProfile()}); setErr != nil {
+			ui.Warn(fmt.Sprintf("Could not save config: %v", setErr))
 		} else {
-			cfg := &config.Config{BaseDir: selected}
+			ui.Success(fmt.Sprintf("Base folder set to %s", stored))
 
 			// Prompt for editor choice
 			fmt.Println()
 			ui.Info("Choose your editor (you can change this later in Settings).")
 			fmt.Println()
 			editor, edErr := SetEditor()
-			if edErr != nil {
-				ui.Muted("Skipped — editor will be auto-detected. Change it in Settings.")
-			} else {
-				cfg.Editor = editor
-			}
-
-			if saveErr := config.Save(cfg); saveErr != nil {
-				ui.Warn("Could not save config")
+			if edErr != nil || editor == "" {
+				ui.Muted("Editor: auto-detect")
+			} else if editor, edErr = config.Set("editor", editor, config.Scope{Profile: config.ActiveProfile()}); edErr != nil {
+				ui.Warn(fmt.Sprintf("Could not set editor: %v", edErr))
+				ui.Muted("Editor will be auto-detected. Change it in Settings.")
 			} else {
-				ui.Success(fmt.Sprintf("Base folder set to %s", selected))
-				if cfg.Editor != "" {
-					ui.Success(fmt.Sprintf("Editor set to %s", cfg.Editor))
-				} else {
-					ui.Muted("Editor: auto-detect")
-				}
+				ui.Success(fmt.Sprintf("Editor set to %s", editor))
 			}
 		}
 	}
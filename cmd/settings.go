@@ -3,11 +3,22 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/vanderhaka/treework/internal/config"
 	"github.com/vanderhaka/treework/internal/ui"
+	"github.com/spf13/cobra"
 )
 
+var settingsCmd = &cobra.Command{
+	Use:   "settings",
+	Short: "Change base folders or editor interactively",
+	Run: func(cmd *cobra.Command, args []string) {
+		doSettings()
+	},
+}
+
 // SetBaseDir runs the shared path-selection flow with retry on invalid paths.
 // Returns the chosen directory path or an error.
 func SetBaseDir(currentPath string) (string, error) {
@@ -71,41 +82,76 @@ func SetEditor() (string, error) {
 	}
 }
 
-// doChangeBaseDir shows the current base folder and lets the user change it.
-func doChangeBaseDir() {
-	cfg := config.Load()
-	current := config.DevDir()
+// doChangeRoots shows the current base folders and lets the user add or remove one.
+func doChangeRoots() {
+	scope := config.Scope{Profile: config.ActiveProfile()}
 
-	fmt.Println()
-	ui.Info(fmt.Sprintf("Base folder: %s", current))
-	if cfg.BaseDir != "" {
-		ui.Muted("(from config file)")
-	} else if os.Getenv("DEV_DIR") != "" {
-		ui.Muted("(from DEV_DIR env var)")
-	} else {
-		ui.Muted("(not set)")
-	}
-	fmt.Println()
+	for {
+		current, source, _ := config.Value("roots", scope)
+		var roots []string
+		if current != "" {
+			roots = strings.Split(current, ",")
+		}
 
-	selected, err := SetBaseDir(current)
-	if err != nil {
-		if isAbort(err) {
+		fmt.Println()
+		if len(roots) == 0 {
+			ui.Info("Base folders: none")
+		} else {
+			ui.Info("Base folders:")
+			for _, r := range roots {
+				ui.Muted("  • " + r)
+			}
+		}
+		ui.Muted(fmt.Sprintf("(from %s)", source))
+		fmt.Println()
+
+		action, err := ui.SelectRootsAction(len(roots) > 0)
+		if err != nil || action == ui.BackValue {
 			return
 		}
-		return
-	}
 
-	cfg.BaseDir = selected
-	if err := config.Save(cfg); err != nil {
-		ui.Error(fmt.Sprintf("Failed to save config: %v", err))
-		return
-	}
+		switch action {
+		case "add":
+			start := ""
+			if len(roots) > 0 {
+				start = filepath.Dir(roots[len(roots)-1])
+			}
+			selected, err := SetBaseDir(start)
+			if err != nil {
+				continue
+			}
+			roots = append(roots, selected)
+		case "remove":
+			selected, err := ui.SelectRoot(roots)
+			if err != nil || selected == ui.BackValue {
+				continue
+			}
+			var kept []string
+			for _, r := range roots {
+				if r != selected {
+					kept = append(kept, r)
+				}
+			}
+			roots = kept
+		}
 
-	ui.Success(fmt.Sprintf("Base folder set to %s", selected))
+		if len(roots) == 0 {
+			err = config.Unset("roots", scope)
+		} else {
+			_, err = config.Set("roots", strings.Join(roots, ","), scope)
+		}
+		if err != nil {
+			ui.Error(fmt.Sprintf("Failed to save config: %v", err))
+			continue
+		}
+		ui.Success("Base folders updated")
+		warnIfOverridden("roots")
+	}
 }
 
 // doChangeEditor shows the current editor and lets the user change it.
 func doChangeEditor() {
+	scope := config.Scope{Profile: config.ActiveProfile()}
 	editor, source := config.EditorSource()
 
 	fmt.Println()
@@ -125,9 +171,12 @@ func doChangeEditor() {
 		return
 	}
 
-	cfg := config.Load()
-	cfg.Editor = selected
-	if err := config.Save(cfg); err != nil {
+	if selected == "" {
+		err = config.Unset("editor", scope)
+	} else {
+		selected, err = config.Set("editor", selected, scope)
+	}
+	if err != nil {
 		ui.Error(fmt.Sprintf("Failed to save config: %v", err))
 		return
 	}
@@ -137,6 +186,7 @@ func doChangeEditor() {
 	} else {
 		ui.Success(fmt.Sprintf("Editor set to %s", selected))
 	}
+	warnIfOverridden("editor")
 }
 
 // doSettings shows the settings sub-menu in a loop.
@@ -152,8 +202,8 @@ func doSettings() {
 		}
 
 		switch action {
-		case "base_dir":
-			doChangeBaseDir()
+		case "roots":
+			doChangeRoots()
 		case "editor":
 			doChangeEditor()
 		case ui.BackValue:
@@ -0,0 +1,302 @@
+// Package backup snapshots worktrees into git refs before treework destroys
+// unsaved work, so it can be restored later.
+//
+// Each backup is a pair of refs in the main repo:
+//
+//	refs/treework/backup/<repo>/<name>/<timestamp>/worktree  uncommitted + untracked files, as a commit on HEAD
+//	refs/treework/backup/<repo>/<name>/<timestamp>/branch    the branch tip at the time
+package backup
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/vanderhaka/treework/internal/git"
+)
+
+// Prefix is the ref namespace all backups live under.
+const Prefix = "refs/treework/backup/"
+
+// timeFormat names backups; it sorts chronologically and is valid in a ref.
+// The milliseconds keep backups of a worktree taken in the same second apart.
+const timeFormat = "20060102T150405.000Z"
+
+// parseFormat reads backup timestamps. time.Parse accepts fractional seconds
+// the layout doesn't mention, so it reads both timeFormat and the whole
+// seconds backups were named with before.
+const parseFormat = "20060102T150405Z"
+
+// Backup is one snapshot of a worktree.
+type Backup struct {
+	ID       string // <repo>/<name>/<timestamp>
+	Repo     string // Repo folder name
+	Name     string // Worktree folder name
+	RepoDir  string // Main repo the refs live in
+	Branch   string // Branch checked out when the backup was taken, or ""
+	Path     string // Worktree folder the backup was taken from
+	Worktree string // SHA of the snapshot commit
+	Tip      string // SHA the branch pointed at, or "" if there was no branch
+	Created  time.Time
+}
+
+// HasChanges reports whether the backup holds uncommitted work on top of the branch.
+func (b Backup) HasChanges() bool {
+	return b.Tip == "" || (b.Worktree != b.Tip && !git.SameTree(b.RepoDir, b.Worktree, b.Tip))
+}
+
+// Create snapshots a worktree and records its branch tip. If the worktree
+// folder is already gone only the branch is backed up.
+func Create(repoDir, wtPath, branch string) (*Backup, error) {
+	now := time.Now().UTC()
+	b := &Backup{
+		Repo:    filepath.Base(repoDir),
+		Name:    filepath.Base(wtPath),
+		RepoDir: repoDir,
+		Branch:  branch,
+		Path:    wtPath,
+		Created: now,
+	}
+	b.ID = fmt.Sprintf("%s/%s/%s", b.Repo, b.Name, now.Format(timeFormat))
+	if branch == "HEAD" {
+		b.Branch = ""
+	}
+	if b.Branch != "" {
+		b.Tip = git.RevParse(repoDir, "refs/heads/"+b.Branch)
+	}
+
+	if _, err := os.Stat(wtPath); err == nil {
+		var sha string
+		if isCheckout(wtPath) {
+			sha, err = git.Snapshot(wtPath, b.message())
+		} else {
+			// An orphan folder git can't see into; its files are saved on
+			// top of the branch it was for, or the default branch
+			base := b.Tip
+			if base == "" {
+				base = git.RevParse(repoDir, git.DefaultBranch(repoDir))
+			}
+			sha, err = git.SnapshotDir(repoDir, wtPath, base, b.message())
+		}
+		if err != nil {
+			return nil, fmt.Errorf("snapshot failed: %w", err)
+		}
+		b.Worktree = sha
+	} else {
+		b.Worktree = b.Tip
+	}
+	if b.Worktree == "" {
+		return nil, fmt.Errorf("nothing to back up")
+	}
+
+	if err := b.createRefs(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// isCheckout reports whether git can work in dir as a checkout of its own,
+// rather than dir being a broken worktree or a folder inside another repo.
+func isCheckout(dir string) bool {
+	root := git.RepoRoot(dir)
+	real, err := filepath.EvalSymlinks(dir)
+	return root != "" && err == nil && root == real
+}
+
+// message is the snapshot commit's message. The trailers let List recover
+// the branch and path later.
+func (b Backup) message() string {
+	return fmt.Sprintf("treework backup of %s\n\nBranch: %s\nPath: %s\n", b.Name, b.Branch, b.Path)
+}
+
+// createRefs stores the backup's refs. It fails rather than overwrite
+// another backup with the same ID.
+func (b Backup) createRefs() error {
+	if err := git.CreateRef(b.RepoDir, b.ref("worktree"), b.Worktree); err != nil {
+		return fmt.Errorf("backup %s: %w", b.ID, err)
+	}
+	if b.Tip != "" {
+		if err := git.CreateRef(b.RepoDir, b.ref("branch"), b.Tip); err != nil {
+			git.DeleteRef(b.RepoDir, b.ref("worktree"))
+			return fmt.Errorf("backup %s: %w", b.ID, err)
+		}
+	}
+	return nil
+}
+
+// ref returns the full name of one of the backup's refs.
+func (b Backup) ref(kind string) string {
+	return Prefix + b.ID + "/" + kind
+}
+
+// List returns the backups stored in a repo, oldest first.
+func List(repoDir string) []Backup {
+	byID := make(map[string]*Backup)
+	for _, r := range git.ListRefs(repoDir, Prefix) {
+		rest := strings.TrimPrefix(r.Name, Prefix)
+		id, kind := filepath.Dir(rest), filepath.Base(rest)
+		parts := strings.Split(id, "/")
+		if len(parts) != 3 {
+			continue
+		}
+		created, err := time.Parse(parseFormat, parts[2])
+		if err != nil {
+			continue
+		}
+		b, ok := byID[id]
+		if !ok {
+			b = &Backup{ID: id, Repo: parts[0], Name: parts[1], RepoDir: repoDir, Created: created}
+			byID[id] = b
+		}
+		switch kind {
+		case "worktree":
+			b.Worktree = r.SHA
+		case "branch":
+			b.Tip = r.SHA
+		}
+	}
+
+	var backups []Backup
+	for _, b := range byID {
+		if b.Worktree == "" {
+			b.Worktree = b.Tip
+		}
+		b.Branch, b.Path = trailers(git.CommitMessage(repoDir, b.Worktree))
+		backups = append(backups, *b)
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].Created.Before(backups[j].Created)
+	})
+	return backups
+}
+
+// trailers reads the Branch and Path lines written by message.
+func trailers(msg string) (branch, path string) {
+	for _, line := range strings.Split(msg, "\n") {
+		if v, ok := strings.CutPrefix(line, "Branch: "); ok {
+			branch = strings.TrimSpace(v)
+		} else if v, ok := strings.CutPrefix(line, "Path: "); ok {
+			path = strings.TrimSpace(v)
+		}
+	}
+	return branch, path
+}
+
+// Find looks up a backup by full ID, by "<name>/<timestamp>", or by worktree
+// name (the most recent backup of that worktree wins).
+func Find(backups []Backup, query string) (Backup, error) {
+	var latest *Backup
+	for i, b := range backups {
+		if b.ID == query || strings.HasSuffix(b.ID, "/"+query) {
+			return b, nil
+		}
+		if b.Name == query || strings.HasSuffix(b.Name, "-worktree-"+query) {
+			latest = &backups[i] // sorted oldest first, so the last match is newest
+		}
+	}
+	if latest == nil {
+		return Backup{}, fmt.Errorf("no backup matching '%s' — run 'treework backups list'", query)
+	}
+	return *latest, nil
+}
+
+// Delete removes a backup's refs. The commits are left for git gc to collect.
+func Delete(b Backup) error {
+	if err := git.DeleteRef(b.RepoDir, b.ref("worktree")); err != nil {
+		return err
+	}
+	if b.Tip != "" {
+		return git.DeleteRef(b.RepoDir, b.ref("branch"))
+	}
+	return nil
+}
+
+// Rename files the backups taken from the worktree at oldPath under the
+// folder name of newPath, so they stay with the worktree after it moves.
+// Returns how many backups were renamed.
+func Rename(repoDir, oldPath, newPath string) (int, error) {
+	oldName, newName := filepath.Base(oldPath), filepath.Base(newPath)
+	if oldName == newName {
+		return 0, nil
+	}
+	renamed := 0
+	for _, b := range List(repoDir) {
+		// A backup taken under another name was renamed by an earlier move;
+		// otherwise it must come from this folder, not a worktree that once
+		// had the same name
+		if b.Name != oldName || (filepath.Base(b.Path) == oldName && filepath.Clean(b.Path) != filepath.Clean(oldPath)) {
+			continue
+		}
+		moved := b
+		moved.Name = newName
+		moved.ID = fmt.Sprintf("%s/%s/%s", b.Repo, newName, filepath.Base(b.ID))
+		if err := moved.createRefs(); err != nil {
+			return renamed, err
+		}
+		if err := Delete(b); err != nil {
+			return renamed, err
+		}
+		renamed++
+	}
+	return renamed, nil
+}
+
+// Expired returns the backups in a repo that fall outside retention: older
+// than days, or beyond the newest keep backups of the same worktree.
+func Expired(repoDir string, days, keep int) []Backup {
+	cutoff := time.Now().AddDate(0, 0, -days)
+	perName := make(map[string]int)
+	all := List(repoDir)
+
+	var expired []Backup
+	for i := len(all) - 1; i >= 0; i-- { // newest first
+		b := all[i]
+		perName[b.Name]++
+		if b.Created.Before(cutoff) || perName[b.Name] > keep {
+			expired = append(expired, b)
+		}
+	}
+	return expired
+}
+
+// Restore checks out a backup into a new worktree at wtPath. The original
+// branch is recreated at its backed-up tip if it no longer exists, otherwise
+// a new branch is created there. Uncommitted work comes back unstaged.
+// Returns the branch the new worktree is on.
+func Restore(b Backup, wtPath, newBranch string) (string, error) {
+	branch := b.Branch
+	if branch == "" || git.BranchExists(b.RepoDir, branch) {
+		branch = newBranch
+	}
+	if git.BranchExists(b.RepoDir, branch) {
+		return "", fmt.Errorf("branch '%s' already exists", branch)
+	}
+
+	start := b.Tip
+	if start == "" {
+		start = b.Worktree + "^" // detached backup: start from the commit it was taken on
+	}
+	if err := git.CreateBranch(b.RepoDir, branch, start); err != nil {
+		return "", err
+	}
+	if err := git.WorktreeAdd(b.RepoDir, wtPath, branch, false); err != nil {
+		return "", fmt.Errorf("git worktree add failed: %w", err)
+	}
+	if b.HasChanges() {
+		if err := git.RestoreWorktree(wtPath, b.Worktree); err != nil {
+			return branch, fmt.Errorf("worktree created but restoring changes failed: %w", err)
+		}
+	}
+	return branch, nil
+}
+
+// Apply replays a backup's uncommitted work onto an existing worktree's files.
+func Apply(b Backup, wtPath string) error {
+	if !b.HasChanges() {
+		return nil
+	}
+	return git.ApplyChanges(wtPath, b.Worktree+"^", b.Worktree)
+}
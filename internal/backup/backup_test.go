@@ -0,0 +1,269 @@
+package backup
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vanderhaka/treework/internal/git"
+)
+
+// newRepo creates a repo with one commit and a worktree on branch feat.
+// Returns the repo and worktree paths.
+func newRepo(t *testing.T) (repoDir, wtPath string) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	for _, v := range []string{"GIT_AUTHOR_NAME", "GIT_COMMITTER_NAME"} {
+		t.Setenv(v, "test")
+	}
+	for _, v := range []string{"GIT_AUTHOR_EMAIL", "GIT_COMMITTER_EMAIL"} {
+		t.Setenv(v, "test@example.com")
+	}
+
+	dir := t.TempDir()
+	repoDir = filepath.Join(dir, "app")
+	wtPath = filepath.Join(dir, "app-worktree-feat")
+	run(t, dir, "init", "-q", "-b", "main", repoDir)
+	writeFile(t, filepath.Join(repoDir, "README"), "hello\n")
+	run(t, repoDir, "add", ".")
+	run(t, repoDir, "commit", "-q", "-m", "initial")
+	run(t, repoDir, "worktree", "add", "-q", "-b", "feat", wtPath)
+	return repoDir, wtPath
+}
+
+func run(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCreate(t *testing.T) {
+	tests := []struct {
+		name        string
+		dirty       bool
+		wantChanges bool
+	}{
+		{name: "clean", dirty: false, wantChanges: false},
+		{name: "dirty", dirty: true, wantChanges: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repoDir, wtPath := newRepo(t)
+			if tt.dirty {
+				writeFile(t, filepath.Join(wtPath, "new.txt"), "unsaved\n")
+			}
+
+			b, err := Create(repoDir, wtPath, "feat")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if b.HasChanges() != tt.wantChanges {
+				t.Errorf("HasChanges = %v, want %v", b.HasChanges(), tt.wantChanges)
+			}
+
+			backups := List(repoDir)
+			if len(backups) != 1 {
+				t.Fatalf("List returned %d backups, want 1", len(backups))
+			}
+			got := backups[0]
+			if got.ID != b.ID || got.Name != "app-worktree-feat" || got.Branch != "feat" || got.Path != wtPath {
+				t.Errorf("List = %+v, want the backup just taken (%s)", got, b.ID)
+			}
+			if got.Tip != git.RevParse(repoDir, "refs/heads/feat") {
+				t.Errorf("Tip = %s, want the branch tip", got.Tip)
+			}
+		})
+	}
+}
+
+func TestCreateIDsDontCollide(t *testing.T) {
+	repoDir, wtPath := newRepo(t)
+	writeFile(t, filepath.Join(wtPath, "new.txt"), "unsaved\n")
+
+	const n = 5
+	ids := make(map[string]bool)
+	for i := 0; i < n; i++ {
+		b, err := Create(repoDir, wtPath, "feat")
+		if err != nil {
+			t.Fatalf("backup %d: %v", i+1, err)
+		}
+		if ids[b.ID] {
+			t.Fatalf("backup %d reused ID %s", i+1, b.ID)
+		}
+		ids[b.ID] = true
+		time.Sleep(2 * time.Millisecond)
+	}
+	if got := len(List(repoDir)); got != n {
+		t.Errorf("List returned %d backups, want %d", got, n)
+	}
+}
+
+func TestCreateRefsRefusesToOverwrite(t *testing.T) {
+	repoDir, wtPath := newRepo(t)
+	b, err := Create(repoDir, wtPath, "feat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := git.RevParse(repoDir, b.ref("worktree"))
+
+	other := *b
+	other.Worktree = run(t, repoDir, "commit-tree", "-m", "other", "HEAD^{tree}")
+	if err := other.createRefs(); err == nil {
+		t.Fatal("createRefs overwrote an existing backup")
+	}
+	if got := git.RevParse(repoDir, b.ref("worktree")); got != before {
+		t.Errorf("worktree ref moved to %s, want %s", got, before)
+	}
+}
+
+func TestFind(t *testing.T) {
+	backups := []Backup{
+		{ID: "app/app-worktree-feat/20260101T000000Z", Name: "app-worktree-feat"},
+		{ID: "app/app-worktree-feat/20260102T000000.500Z", Name: "app-worktree-feat"},
+		{ID: "app/app-worktree-other/20260103T000000.000Z", Name: "app-worktree-other"},
+	}
+	tests := []struct {
+		query   string
+		wantID  string
+		wantErr bool
+	}{
+		{query: "app/app-worktree-feat/20260101T000000Z", wantID: backups[0].ID},
+		{query: "app-worktree-feat/20260101T000000Z", wantID: backups[0].ID},
+		{query: "app-worktree-feat", wantID: backups[1].ID},
+		{query: "feat", wantID: backups[1].ID},
+		{query: "other", wantID: backups[2].ID},
+		{query: "missing", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			b, err := Find(backups, tt.query)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("found %s, want an error", b.ID)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if b.ID != tt.wantID {
+				t.Errorf("found %s, want %s", b.ID, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestExpired(t *testing.T) {
+	repoDir, _ := newRepo(t)
+	head := git.RevParse(repoDir, "HEAD")
+	now := time.Now().UTC()
+
+	// Backups named by age, in both the current and the older ID format
+	backups := []struct {
+		name   string
+		age    time.Duration
+		legacy bool
+	}{
+		{name: "app-worktree-a", age: 1 * time.Hour},
+		{name: "app-worktree-a", age: 2 * time.Hour},
+		{name: "app-worktree-a", age: 3 * time.Hour},
+		{name: "app-worktree-b", age: 40 * 24 * time.Hour},
+		{name: "app-worktree-c", age: 24 * time.Hour, legacy: true},
+		{name: "app-worktree-d", age: 35 * 24 * time.Hour, legacy: true},
+	}
+	ids := make([]string, len(backups))
+	for i, b := range backups {
+		format := timeFormat
+		if b.legacy {
+			format = "20060102T150405Z"
+		}
+		ids[i] = "app/" + b.name + "/" + now.Add(-b.age).Format(format)
+		if err := git.UpdateRef(repoDir, Prefix+ids[i]+"/worktree", head); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := len(List(repoDir)); got != len(backups) {
+		t.Fatalf("List returned %d backups, want %d", got, len(backups))
+	}
+
+	tests := []struct {
+		name       string
+		days, keep int
+		want       []string
+	}{
+		{name: "defaults", days: 30, keep: 10, want: []string{ids[3], ids[5]}},
+		{name: "keep two per worktree", days: 30, keep: 2, want: []string{ids[2], ids[3], ids[5]}},
+		{name: "keep one per worktree", days: 365, keep: 1, want: []string{ids[1], ids[2]}},
+		{name: "short retention", days: 0, keep: 10, want: ids},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, b := range Expired(repoDir, tt.days, tt.keep) {
+				got = append(got, b.ID)
+			}
+			sort.Strings(got)
+			want := append([]string(nil), tt.want...)
+			sort.Strings(want)
+			if strings.Join(got, "\n") != strings.Join(want, "\n") {
+				t.Errorf("Expired =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+			}
+		})
+	}
+}
+
+func TestRename(t *testing.T) {
+	repoDir, wtPath := newRepo(t)
+	if _, err := Create(repoDir, wtPath, "feat"); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(2 * time.Millisecond)
+
+	// A backup of an earlier worktree that had the same name but lived elsewhere
+	elsewhere := filepath.Join(t.TempDir(), "app-worktree-feat")
+	run(t, repoDir, "worktree", "add", "-q", "-b", "old", elsewhere)
+	if _, err := Create(repoDir, elsewhere, "old"); err != nil {
+		t.Fatal(err)
+	}
+
+	newPath := filepath.Join(filepath.Dir(wtPath), "app-worktree-renamed")
+	n, err := Rename(repoDir, wtPath, newPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("renamed %d backups, want 1", n)
+	}
+
+	names := make(map[string]string)
+	for _, b := range List(repoDir) {
+		names[b.Branch] = b.Name
+	}
+	if names["feat"] != "app-worktree-renamed" {
+		t.Errorf("backup of the moved worktree is named %q, want app-worktree-renamed", names["feat"])
+	}
+	if names["old"] != "app-worktree-feat" {
+		t.Errorf("backup of the other worktree is named %q, want it left alone", names["old"])
+	}
+
+	// A second move finds the backups under their new name
+	third := filepath.Join(filepath.Dir(wtPath), "app-worktree-third")
+	if n, err := Rename(repoDir, newPath, third); err != nil || n != 1 {
+		t.Errorf("second Rename = %d, %v; want 1 backup renamed", n, err)
+	}
+}
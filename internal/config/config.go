@@ -1,84 +1,630 @@
 package config
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/vanderhaka/treework/internal/fileutil"
 )
 
+// CurrentVersion is the config schema version written by this build.
+// Bump it and append to migrations when the file format changes.
+const CurrentVersion = 2
+
+// Default backup retention, used when backups.retention_days/keep aren't set.
+const (
+	DefaultBackupRetentionDays = 30
+	DefaultBackupKeep          = 10
+)
+
+// DefaultLayout is where new worktrees go, relative to the repo's parent folder.
+const DefaultLayout = "{repo}-worktree-{name}"
+
+// DefaultProtectedBranches are never deleted when protected_branches isn't set.
+var DefaultProtectedBranches = []string{"main", "master"}
+
+// DefaultScanDepth is how many folders below a base folder repos are looked for.
+const DefaultScanDepth = 4
+
+// DefaultScanIgnore are folders never searched for repos when scan_ignore isn't set.
+var DefaultScanIgnore = []string{"node_modules", "vendor", ".venv", "__pycache__", "target", "dist"}
+
 // Config holds persistent application settings.
 type Config struct {
-	BaseDir string `json:"base_dir"`
-	Editor  string `json:"editor,omitempty"`
+	Version int `json:"version"`
+	Settings
+
+	Profile  string              `json:"profile,omitempty"`  // Profile used when none is selected
+	Profiles map[string]Settings `json:"profiles,omitempty"` // Named alternatives to the top-level settings
+
+	// raw is the file as read (after migrating). Saving merges it back in so
+	// keys this build doesn't know about, written by a newer treework or by
+	// hand, are never dropped, however deeply they're nested.
+	raw json.RawMessage
+}
+
+// Settings is a set of base folders plus defaults for repos inside them.
+// The top level of the config file is a Settings, and so is each profile.
+type Settings struct {
+	Roots []Root `json:"roots,omitempty"`
+	Options
+
+	FavoriteRepos []string          `json:"favorite_repos,omitempty"` // Repo paths pinned to the top of the repo picker
+	RepoAliases   map[string]string `json:"repo_aliases,omitempty"`   // Short names for --repo, e.g. "api" → a repo path
+}
+
+// Root is a base folder containing git repos. Its options override the
+// profile and top-level options for every repo inside it.
+type Root struct {
+	Path string `json:"path"`
+	Options
+}
+
+// Options are the settings that can be given globally, per profile or per root.
+type Options struct {
+	Editor  string   `json:"editor,omitempty"`
+	Layout  string   `json:"layout,omitempty"` // e.g. "{repo}.worktrees/{name}"
+	Hooks   *Hooks   `json:"hooks,omitempty"`
+	Backups *Backups `json:"backups,omitempty"`
+	Merge   *Merge   `json:"merge,omitempty"`
+	Scan    *Scan    `json:"scan,omitempty"`
+
+	ProtectedBranches []string `json:"protected_branches,omitempty"` // Glob patterns, e.g. "release/*"
 }
 
-// configPath returns the path to the config file.
-func configPath() string {
+// Hooks are shell commands run inside a worktree at points in its life.
+type Hooks struct {
+	PostCreate string `json:"post_create,omitempty"`
+	PreRemove  string `json:"pre_remove,omitempty"`
+}
+
+// Backups controls how long the snapshots taken before force-removing a
+// worktree are kept. Zero means use the default.
+type Backups struct {
+	RetentionDays int `json:"retention_days,omitempty"` // Delete backups older than this
+	Keep          int `json:"keep,omitempty"`           // Keep at most this many per worktree
+}
+
+// Merge controls what merged-branch checks compare against. Empty fields
+// are detected from the repo.
+type Merge struct {
+	Remote        string `json:"remote,omitempty"`         // e.g. "upstream" in a fork
+	DefaultBranch string `json:"default_branch,omitempty"` // e.g. "develop"
+	Fetch         *bool  `json:"fetch,omitempty"`          // Fetch the default branch before checking
+}
+
+// Scan controls how base folders are searched for repos. Zero values mean
+// use the default.
+type Scan struct {
+	Depth  int      `json:"depth,omitempty"`  // Folders below the base folder a repo may be
+	Ignore []string `json:"ignore,omitempty"` // Globs for folder names (or paths, if they contain /) not to search
+	Nested *bool    `json:"nested,omitempty"` // Look for repos inside other repos
+}
+
+// migrations[i] upgrades a raw config from schema version i to i+1.
+var migrations = []func(raw map[string]json.RawMessage) error{
+	// v0 → v1: unversioned files from treework 0.1.0. Same keys, just stamped.
+	func(raw map[string]json.RawMessage) error { return nil },
+
+	// v1 → v2: a single base_dir becomes the first entry in roots.
+	func(raw map[string]json.RawMessage) error {
+		v, ok := raw["base_dir"]
+		if !ok {
+			return nil
+		}
+		delete(raw, "base_dir")
+		var dir string
+		if err := json.Unmarshal(v, &dir); err != nil {
+			return fmt.Errorf("base_dir must be a string, got %s", v)
+		}
+		if dir == "" {
+			return nil
+		}
+		roots, err := json.Marshal([]Root{{Path: dir}})
+		if err != nil {
+			return err
+		}
+		raw["roots"] = roots
+		return nil
+	},
+}
+
+// Path returns the path to the config file, honoring $XDG_CONFIG_HOME.
+func Path() string {
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); filepath.IsAbs(xdg) {
+		return filepath.Join(xdg, "treework", "config.json")
+	}
 	home, _ := os.UserHomeDir()
 	return filepath.Join(home, ".config", "treework", "config.json")
 }
 
+// StateDir returns the folder for treework's own records (parked worktrees,
+// the operation journal), honoring $XDG_STATE_HOME.
+func StateDir() string {
+	if xdg := os.Getenv("XDG_STATE_HOME"); filepath.IsAbs(xdg) {
+		return filepath.Join(xdg, "treework")
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".local", "state", "treework")
+}
+
+// CacheDir returns the folder for data treework can rebuild (the repo index),
+// honoring $XDG_CACHE_HOME and otherwise using the user cache folder.
+func CacheDir() string {
+	if xdg := os.Getenv("XDG_CACHE_HOME"); filepath.IsAbs(xdg) {
+		return filepath.Join(xdg, "treework")
+	}
+	if dir, err := os.UserCacheDir(); err == nil {
+		return filepath.Join(dir, "treework")
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".cache", "treework")
+}
+
 // FileExists returns true if the config file exists on disk.
 func FileExists() bool {
-	_, err := os.Stat(configPath())
+	_, err := os.Stat(Path())
 	return err == nil
 }
 
-// Load reads the config file from disk. Returns defaults if the file is missing or unreadable.
-func Load() *Config {
-	cfg := &Config{}
-	data, err := os.ReadFile(configPath())
+// Load reads the config file from disk. Older schema versions are migrated
+// in memory only; the file is upgraded by Migrate or the next write.
+// A missing file returns defaults; a broken or invalid file returns an error
+// describing what's wrong rather than silently falling back to defaults.
+func Load() (*Config, error) {
+	cfg, _, err := load()
+	return cfg, err
+}
+
+// Migrate rewrites a config file written with an older schema version in
+// the current one, keeping the old file as config.json.v<N>.bak. Does
+// nothing if the file is missing or already current.
+func Migrate() error {
+	unlock, err := fileutil.Lock(Path())
 	if err != nil {
-		return cfg
+		return err
+	}
+	defer unlock()
+
+	cfg, old, err := load()
+	if err != nil || old == nil {
+		return err
 	}
-	_ = json.Unmarshal(data, cfg)
-	return cfg
+	return save(cfg, old)
+}
+
+// oldFile is a config file as it was before migrating.
+type oldFile struct {
+	version int
+	data    []byte
 }
 
-// Save writes the config to disk, creating the directory if needed.
+// load reads and parses the config file without writing anything back.
+// If it had to be migrated the original is returned too, for save to keep.
+func load() (cfg *Config, old *oldFile, err error) {
+	p := Path()
+	data, err := os.ReadFile(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Config{Version: CurrentVersion}, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot read %s: %w", p, err)
+	}
+
+	cfg, from, err := parse(data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid config file %s: %w", p, err)
+	}
+	if from == CurrentVersion {
+		return cfg, nil, nil
+	}
+	return cfg, &oldFile{version: from, data: data}, nil
+}
+
+var (
+	cacheMu sync.Mutex
+	cached  *Config
+)
+
+// current returns the config for read-only lookups, falling back to defaults
+// when the file is broken. The broken file is reported once at startup.
+// The file is read once and kept until treework next writes it.
+func current() *Config {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	if cached == nil {
+		cfg, err := Load()
+		if err != nil {
+			cfg = &Config{Version: CurrentVersion}
+		}
+		cached = cfg
+	}
+	return cached
+}
+
+// forget drops the config current has cached, so the next lookup reads the
+// file again.
+func forget() {
+	cacheMu.Lock()
+	cached = nil
+	cacheMu.Unlock()
+}
+
+// parse decodes, migrates and validates raw config data.
+// Returns the schema version the data was written with.
+func parse(data []byte) (cfg *Config, fromVersion int, err error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, 0, describeJSONError(data, err)
+	}
+	if raw == nil {
+		return nil, 0, fmt.Errorf("expected a JSON object")
+	}
+
+	version := 0
+	if v, ok := raw["version"]; ok {
+		if err := json.Unmarshal(v, &version); err != nil || version < 0 {
+			return nil, 0, fmt.Errorf("version must be a whole number")
+		}
+	}
+	if version > CurrentVersion {
+		return nil, 0, fmt.Errorf("written by a newer treework (schema v%d, this version supports up to v%d) — please upgrade treework", version, CurrentVersion)
+	}
+
+	from := version
+	for ; version < CurrentVersion; version++ {
+		if err := migrations[version](raw); err != nil {
+			return nil, 0, fmt.Errorf("migrating from schema v%d: %w", version, err)
+		}
+	}
+	raw["version"] = json.RawMessage(fmt.Sprint(CurrentVersion))
+
+	cfg = &Config{}
+	normalized, _ := json.Marshal(raw)
+	if err := json.Unmarshal(normalized, cfg); err != nil {
+		return nil, 0, describeJSONError(normalized, err)
+	}
+	cfg.raw = normalized
+
+	if err := cfg.Validate(); err != nil {
+		return nil, 0, err
+	}
+
+	return cfg, from, nil
+}
+
+// jsonFields returns the JSON key names of a struct's exported fields,
+// including those promoted from embedded structs, with their types.
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			for name, ft := range jsonFields(f.Type) {
+				fields[name] = ft
+			}
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "" {
+			name = f.Name
+		}
+		if name != "-" {
+			fields[name] = f.Type
+		}
+	}
+	return fields
+}
+
+// keepUnknown merges the keys of orig that type t doesn't define into known,
+// the JSON of a value of type t, at every level. Keys t does define are
+// taken from known, so settings that were removed stay removed. Entries in
+// lists of objects are matched up by their path, since roots is the only
+// such list.
+func keepUnknown(orig, known json.RawMessage, t reflect.Type) json.RawMessage {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		var o, k map[string]json.RawMessage
+		if json.Unmarshal(orig, &o) != nil || json.Unmarshal(known, &k) != nil || o == nil || k == nil {
+			return known
+		}
+		fields := jsonFields(t)
+		for name, v := range o {
+			ft, isField := fields[name]
+			if kv, ok := k[name]; ok && isField {
+				k[name] = keepUnknown(v, kv, ft)
+			} else if !isField {
+				k[name] = v
+			}
+		}
+		return marshalOr(k, known)
+
+	case reflect.Map:
+		var o, k map[string]json.RawMessage
+		if json.Unmarshal(orig, &o) != nil || json.Unmarshal(known, &k) != nil {
+			return known
+		}
+		for name, kv := range k {
+			if v, ok := o[name]; ok {
+				k[name] = keepUnknown(v, kv, t.Elem())
+			}
+		}
+		return marshalOr(k, known)
+
+	case reflect.Slice:
+		var o, k []json.RawMessage
+		if json.Unmarshal(orig, &o) != nil || json.Unmarshal(known, &k) != nil {
+			return known
+		}
+		byPath := make(map[string]json.RawMessage)
+		for _, v := range o {
+			if p := pathOf(v); p != "" {
+				byPath[p] = v
+			}
+		}
+		for i, kv := range k {
+			if v, ok := byPath[pathOf(kv)]; ok {
+				k[i] = keepUnknown(v, kv, t.Elem())
+			}
+		}
+		return marshalOr(k, known)
+	}
+	return known
+}
+
+// pathOf returns the "path" key of a JSON object, or "".
+func pathOf(v json.RawMessage) string {
+	var obj struct {
+		Path string `json:"path"`
+	}
+	if json.Unmarshal(v, &obj) != nil {
+		return ""
+	}
+	return obj.Path
+}
+
+// marshalOr marshals v, falling back to fallback if that fails.
+func marshalOr(v any, fallback json.RawMessage) json.RawMessage {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fallback
+	}
+	return data
+}
+
+// Validate checks values that decode fine but make no sense,
+// returning every problem at once.
+func (c *Config) Validate() error {
+	errs := c.Settings.validate("")
+	for name, p := range c.Profiles {
+		if strings.TrimSpace(name) == "" {
+			errs = append(errs, fmt.Errorf("profile names cannot be blank"))
+			continue
+		}
+		errs = append(errs, p.validate(fmt.Sprintf("profiles.%s.", name))...)
+	}
+	if c.Profile != "" {
+		if _, ok := c.Profiles[c.Profile]; !ok {
+			errs = append(errs, fmt.Errorf("profile %q is not defined under profiles", c.Profile))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s Settings) validate(prefix string) []error {
+	errs := s.Options.validate(prefix)
+	seen := make(map[string]bool)
+	for i, r := range s.Roots {
+		at := fmt.Sprintf("%sroots[%d].", prefix, i)
+		if !filepath.IsAbs(r.Path) {
+			errs = append(errs, fmt.Errorf("%spath must be an absolute path, got %q", at, r.Path))
+		}
+		if seen[filepath.Clean(r.Path)] {
+			errs = append(errs, fmt.Errorf("%spath %q is listed more than once", at, r.Path))
+		}
+		seen[filepath.Clean(r.Path)] = true
+		errs = append(errs, r.Options.validate(at)...)
+	}
+	for _, f := range s.FavoriteRepos {
+		if !filepath.IsAbs(f) {
+			errs = append(errs, fmt.Errorf("%sfavorite_repos must hold absolute paths, got %q", prefix, f))
+		}
+	}
+	for name, p := range s.RepoAliases {
+		if err := ValidateAlias(name); err != nil {
+			errs = append(errs, fmt.Errorf("%srepo_aliases: %v", prefix, err))
+		}
+		if !filepath.IsAbs(p) {
+			errs = append(errs, fmt.Errorf("%srepo_aliases.%s must be an absolute path, got %q", prefix, name, p))
+		}
+	}
+	return errs
+}
+
+// ValidateAlias checks that a repo alias can be typed after --repo and
+// stored in the comma-separated repo_aliases list.
+func ValidateAlias(name string) error {
+	if name == "" {
+		return fmt.Errorf("alias cannot be empty")
+	}
+	if strings.ContainsAny(name, " \t,=/") {
+		return fmt.Errorf("alias '%s' cannot contain spaces, commas, '=' or '/'", name)
+	}
+	return nil
+}
+
+func (o Options) validate(prefix string) []error {
+	var errs []error
+	if o.Editor != "" && strings.TrimSpace(o.Editor) == "" {
+		errs = append(errs, fmt.Errorf("%seditor cannot be blank — remove the key to auto-detect", prefix))
+	}
+	if o.Layout != "" {
+		if err := ValidateLayout(o.Layout); err != nil {
+			errs = append(errs, fmt.Errorf("%slayout: %w", prefix, err))
+		}
+	}
+	if o.Backups != nil {
+		if o.Backups.RetentionDays < 0 {
+			errs = append(errs, fmt.Errorf("%sbackups.retention_days cannot be negative", prefix))
+		}
+		if o.Backups.Keep < 0 {
+			errs = append(errs, fmt.Errorf("%sbackups.keep cannot be negative", prefix))
+		}
+	}
+	for _, p := range o.ProtectedBranches {
+		if _, err := path.Match(p, ""); err != nil || strings.TrimSpace(p) == "" {
+			errs = append(errs, fmt.Errorf("%sprotected_branches: '%s' is not a valid pattern", prefix, p))
+		}
+	}
+	if o.Merge != nil {
+		if strings.ContainsAny(o.Merge.Remote, " \t") {
+			errs = append(errs, fmt.Errorf("%smerge.remote cannot contain spaces", prefix))
+		}
+		if strings.ContainsAny(o.Merge.DefaultBranch, " \t") {
+			errs = append(errs, fmt.Errorf("%smerge.default_branch cannot contain spaces", prefix))
+		}
+	}
+	if o.Scan != nil {
+		if o.Scan.Depth < 0 {
+			errs = append(errs, fmt.Errorf("%sscan.depth cannot be negative", prefix))
+		}
+		for _, p := range o.Scan.Ignore {
+			if _, err := path.Match(p, ""); err != nil || strings.TrimSpace(p) == "" {
+				errs = append(errs, fmt.Errorf("%sscan.ignore: '%s' is not a valid pattern", prefix, p))
+			}
+		}
+	}
+	return errs
+}
+
+// ValidateLayout checks a worktree layout template.
+func ValidateLayout(layout string) error {
+	if !strings.Contains(layout, "{name}") {
+		return fmt.Errorf("must contain {name}")
+	}
+	if filepath.IsAbs(layout) {
+		return fmt.Errorf("must be relative to the repo's parent folder")
+	}
+	return nil
+}
+
+// describeJSONError turns encoding/json errors into messages with line numbers
+// and key names instead of byte offsets and Go types.
+func describeJSONError(data []byte, err error) error {
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		line, col := position(data, syntaxErr.Offset)
+		return fmt.Errorf("line %d, column %d: %v", line, col, syntaxErr)
+	}
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		want := typeErr.Type.Kind().String()
+		switch want {
+		case "int":
+			want = "a number"
+		case "string":
+			want = "a string"
+		case "bool":
+			want = "true or false"
+		case "slice":
+			want = "a list"
+		case "map", "struct":
+			want = "an object"
+		}
+		if typeErr.Field == "" {
+			return fmt.Errorf("expected a JSON object, got %s", typeErr.Value)
+		}
+		return fmt.Errorf("%s must be %s, got %s", typeErr.Field, want, typeErr.Value)
+	}
+	return err
+}
+
+// position converts a byte offset into a 1-based line and column.
+func position(data []byte, offset int64) (line, col int) {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	before := data[:offset]
+	line = bytes.Count(before, []byte("\n")) + 1
+	col = int(offset) - bytes.LastIndexByte(before, '\n')
+	return line, col
+}
+
+// Save validates the config and writes it to disk atomically under a file lock.
 func Save(cfg *Config) error {
-	p := configPath()
-	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+	unlock, err := fileutil.Lock(Path())
+	if err != nil {
 		return err
 	}
-	data, err := json.MarshalIndent(cfg, "", "  ")
+	defer unlock()
+	_, old, _ := load()
+	return save(cfg, old)
+}
+
+// Update loads the config, applies fn and saves the result while holding the
+// file lock, so concurrent treework processes can't overwrite each other.
+func Update(fn func(cfg *Config) error) error {
+	unlock, err := fileutil.Lock(Path())
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(p, data, 0o644)
-}
+	defer unlock()
 
-// DevDir returns the root development directory.
-// Returns empty string if not configured — caller must handle setup.
-func DevDir() string {
-	if d := os.Getenv("DEV_DIR"); d != "" {
-		return d
+	cfg, old, err := load()
+	if err != nil {
+		return err
 	}
-	if cfg := Load(); cfg.BaseDir != "" {
-		return cfg.BaseDir
+	if err := fn(cfg); err != nil {
+		return err
 	}
-	return ""
+	return save(cfg, old)
 }
 
-// Editor returns the preferred editor command.
-// Priority: WT_EDITOR env var > config file > "" (auto-detect).
-func Editor() string {
-	if e := os.Getenv("WT_EDITOR"); e != "" {
-		return e
+// save writes the config without taking the lock. Unknown keys are kept.
+// If the file on disk is an older schema version (old), it's kept as a
+// .bak first in case the migration needs undoing.
+func save(cfg *Config, old *oldFile) error {
+	cfg.Version = CurrentVersion
+	if err := cfg.Validate(); err != nil {
+		return err
 	}
-	if cfg := Load(); cfg.Editor != "" {
-		return cfg.Editor
+	if old != nil {
+		backup := fmt.Sprintf("%s.v%d.bak", Path(), old.version)
+		if _, err := os.Stat(backup); errors.Is(err, os.ErrNotExist) {
+			if err := fileutil.WriteAtomic(backup, old.data, 0o644); err != nil {
+				return err
+			}
+		}
 	}
-	return ""
-}
 
-// EditorSource returns the current editor and a human-readable source description.
-func EditorSource() (editor, source string) {
-	if e := os.Getenv("WT_EDITOR"); e != "" {
-		return e, "WT_EDITOR env var"
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return err
 	}
-	if cfg := Load(); cfg.Editor != "" {
-		return cfg.Editor, "config file"
+	orig := cfg.raw
+	if orig == nil {
+		orig = json.RawMessage("{}") // Still goes through keepUnknown, so keys come out sorted
+	}
+	data = keepUnknown(orig, data, reflect.TypeOf(*cfg))
+	var out bytes.Buffer
+	if err := json.Indent(&out, data, "", "  "); err != nil {
+		return err
 	}
-	return "", "auto-detect"
+	out.WriteByte('\n')
+	defer forget()
+	return fileutil.WriteAtomic(Path(), out.Bytes(), 0o644)
 }
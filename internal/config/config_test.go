@@ -0,0 +1,412 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// useTempConfig points the config file at a fresh temp folder and writes
+// data to it, unless data is empty.
+func useTempConfig(t *testing.T, data string) string {
+	t.Helper()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("DEV_DIR", "")
+	t.Setenv("TREEWORK_PROFILE", "")
+	forget()
+	t.Cleanup(forget)
+
+	p := Path()
+	if data != "" {
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return p
+}
+
+// readJSON reads the config file back as generic JSON.
+func readJSON(t *testing.T, p string) map[string]any {
+	t.Helper()
+	data, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v map[string]any
+	if err := json.Unmarshal(data, &v); err != nil {
+		t.Fatalf("config file isn't valid JSON: %v\n%s", err, data)
+	}
+	return v
+}
+
+func TestParseMigrates(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      string
+		wantFrom  int
+		wantRoots []string
+		wantErr   string
+	}{
+		{
+			name:      "v0 with base_dir",
+			data:      `{"base_dir": "/src", "editor": "code"}`,
+			wantFrom:  0,
+			wantRoots: []string{"/src"},
+		},
+		{
+			name:      "v1 with base_dir",
+			data:      `{"version": 1, "base_dir": "/src"}`,
+			wantFrom:  1,
+			wantRoots: []string{"/src"},
+		},
+		{
+			name:     "v1 with empty base_dir",
+			data:     `{"version": 1, "base_dir": ""}`,
+			wantFrom: 1,
+		},
+		{
+			name:      "current",
+			data:      `{"version": 2, "roots": [{"path": "/a"}, {"path": "/b"}]}`,
+			wantFrom:  2,
+			wantRoots: []string{"/a", "/b"},
+		},
+		{
+			name:    "base_dir not a string",
+			data:    `{"version": 1, "base_dir": 3}`,
+			wantErr: "base_dir must be a string",
+		},
+		{
+			name:    "newer schema",
+			data:    `{"version": 99}`,
+			wantErr: "newer treework",
+		},
+		{
+			name:    "negative version",
+			data:    `{"version": -1}`,
+			wantErr: "version must be a whole number",
+		},
+		{
+			name:    "syntax error",
+			data:    "{\n  \"editor\": \"code\",\n}",
+			wantErr: "line 3",
+		},
+		{
+			name:    "wrong type",
+			data:    `{"version": 2, "editor": 5}`,
+			wantErr: "editor must be a string",
+		},
+		{
+			name:    "not an object",
+			data:    `null`,
+			wantErr: "expected a JSON object",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, from, err := parse([]byte(tt.data))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if from != tt.wantFrom {
+				t.Errorf("from = %d, want %d", from, tt.wantFrom)
+			}
+			var roots []string
+			for _, r := range cfg.Roots {
+				roots = append(roots, r.Path)
+			}
+			if !reflect.DeepEqual(roots, tt.wantRoots) {
+				t.Errorf("roots = %v, want %v", roots, tt.wantRoots)
+			}
+		})
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	tests := []struct {
+		name       string
+		data       string
+		wantBackup string // "" if no backup should be written
+	}{
+		{name: "v0", data: `{"base_dir": "/src"}`, wantBackup: "config.json.v0.bak"},
+		{name: "v1", data: `{"version": 1, "base_dir": "/src"}`, wantBackup: "config.json.v1.bak"},
+		{name: "current", data: `{"version": 2, "roots": [{"path": "/src"}]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := useTempConfig(t, tt.data)
+
+			// Reading never writes
+			if _, err := Load(); err != nil {
+				t.Fatal(err)
+			}
+			if data, _ := os.ReadFile(p); string(data) != tt.data {
+				t.Fatalf("Load rewrote the file:\n%s", data)
+			}
+
+			if err := Migrate(); err != nil {
+				t.Fatal(err)
+			}
+			got := readJSON(t, p)
+			if tt.wantBackup == "" {
+				if data, _ := os.ReadFile(p); string(data) != tt.data {
+					t.Errorf("Migrate rewrote a current file:\n%s", data)
+				}
+				return
+			}
+			if got["version"] != float64(CurrentVersion) {
+				t.Errorf("version = %v, want %d", got["version"], CurrentVersion)
+			}
+			if _, ok := got["base_dir"]; ok {
+				t.Error("base_dir is still in the file")
+			}
+			backup, err := os.ReadFile(filepath.Join(filepath.Dir(p), tt.wantBackup))
+			if err != nil {
+				t.Fatalf("no backup: %v", err)
+			}
+			if string(backup) != tt.data {
+				t.Errorf("backup = %s, want the original file", backup)
+			}
+		})
+	}
+}
+
+func TestSaveKeepsUnknownKeys(t *testing.T) {
+	const data = `{
+  "version": 2,
+  "future": {"a": 1},
+  "editor": "code",
+  "roots": [
+    {"path": "/a", "root_future": true, "hooks": {"post_create": "make", "hook_future": "x"}},
+    {"path": "/b", "root_future": 2}
+  ],
+  "scan": {"depth": 2, "scan_future": [1, 2]},
+  "profiles": {"work": {"profile_future": "y", "roots": [{"path": "/w", "w_future": 3}]}}
+}`
+	tests := []struct {
+		name  string
+		edit  func(cfg *Config)
+		check func(t *testing.T, got map[string]any)
+	}{
+		{
+			name: "unrelated edit",
+			edit: func(cfg *Config) { cfg.Editor = "vim" },
+			check: func(t *testing.T, got map[string]any) {
+				want := map[string]any{
+					"future":                         map[string]any{"a": float64(1)},
+					"editor":                         "vim",
+					"roots.0.root_future":            true,
+					"roots.0.hooks.post_create":      "make",
+					"roots.0.hooks.hook_future":      "x",
+					"roots.1.root_future":            float64(2),
+					"scan.depth":                     float64(2),
+					"scan.scan_future":               []any{float64(1), float64(2)},
+					"profiles.work.profile_future":   "y",
+					"profiles.work.roots.0.w_future": float64(3),
+					"profiles.work.roots.0.path":     "/w",
+					"roots.0.path":                   "/a",
+					"roots.1.path":                   "/b",
+					"version":                        float64(CurrentVersion),
+				}
+				for key, v := range want {
+					if g := lookup(got, key); !reflect.DeepEqual(g, v) {
+						t.Errorf("%s = %#v, want %#v", key, g, v)
+					}
+				}
+			},
+		},
+		{
+			name: "roots reordered",
+			edit: func(cfg *Config) { cfg.Roots[0], cfg.Roots[1] = cfg.Roots[1], cfg.Roots[0] },
+			check: func(t *testing.T, got map[string]any) {
+				if g := lookup(got, "roots.0.root_future"); g != float64(2) {
+					t.Errorf("roots.0.root_future = %#v, want the one from /b", g)
+				}
+				if g := lookup(got, "roots.1.hooks.hook_future"); g != "x" {
+					t.Errorf("roots.1.hooks.hook_future = %#v, want the one from /a", g)
+				}
+			},
+		},
+		{
+			name: "known keys removed",
+			edit: func(cfg *Config) {
+				cfg.Editor = ""
+				cfg.Roots = cfg.Roots[1:]
+				cfg.Scan = nil
+			},
+			check: func(t *testing.T, got map[string]any) {
+				for _, key := range []string{"editor", "roots.1", "scan.depth"} {
+					if g := lookup(got, key); g != nil {
+						t.Errorf("%s = %#v, want it removed", key, g)
+					}
+				}
+				if g := lookup(got, "roots.0.path"); g != "/b" {
+					t.Errorf("roots.0.path = %#v, want /b", g)
+				}
+				// Unsetting scan takes its unknown keys with it
+				if g := lookup(got, "scan.scan_future"); g != nil {
+					t.Errorf("scan.scan_future = %#v, want scan removed with its known keys", g)
+				}
+				if g := lookup(got, "future.a"); g != float64(1) {
+					t.Errorf("future.a = %#v, want it kept", g)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := useTempConfig(t, data)
+			err := Update(func(cfg *Config) error {
+				tt.edit(cfg)
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.check(t, readJSON(t, p))
+		})
+	}
+}
+
+// lookup follows a dotted path of object keys and list indexes through
+// decoded JSON, returning nil if any part is missing.
+func lookup(v any, key string) any {
+	for _, part := range strings.Split(key, ".") {
+		switch x := v.(type) {
+		case map[string]any:
+			v = x[part]
+		case []any:
+			i, err := strconv.Atoi(part)
+			if err != nil || i < 0 || i >= len(x) {
+				return nil
+			}
+			v = x[i]
+		default:
+			return nil
+		}
+	}
+	return v
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{name: "valid", data: `{"roots": [{"path": "/a", "layout": "{repo}/{name}"}], "protected_branches": ["release/*"]}`},
+		{name: "relative root", data: `{"roots": [{"path": "src"}]}`, wantErr: "roots[0].path must be an absolute path"},
+		{name: "duplicate root", data: `{"roots": [{"path": "/a"}, {"path": "/a/"}]}`, wantErr: "listed more than once"},
+		{name: "layout without name", data: `{"layout": "{repo}-wt"}`, wantErr: "layout: must contain {name}"},
+		{name: "absolute layout", data: `{"layout": "/wt/{name}"}`, wantErr: "must be relative"},
+		{name: "blank editor", data: `{"editor": "  "}`, wantErr: "editor cannot be blank"},
+		{name: "negative keep", data: `{"backups": {"keep": -1}}`, wantErr: "backups.keep cannot be negative"},
+		{name: "negative depth", data: `{"scan": {"depth": -2}}`, wantErr: "scan.depth cannot be negative"},
+		{name: "bad pattern", data: `{"protected_branches": ["[x"]}`, wantErr: "'[x' is not a valid pattern"},
+		{name: "remote with space", data: `{"merge": {"remote": "up stream"}}`, wantErr: "merge.remote cannot contain spaces"},
+		{name: "bad alias", data: `{"repo_aliases": {"a b": "/a"}}`, wantErr: "cannot contain spaces"},
+		{name: "relative alias", data: `{"repo_aliases": {"api": "api"}}`, wantErr: "repo_aliases.api must be an absolute path"},
+		{name: "undefined profile", data: `{"profile": "work"}`, wantErr: `profile "work" is not defined`},
+		{name: "profile errors are prefixed", data: `{"profiles": {"work": {"roots": [{"path": "x"}]}}}`, wantErr: "profiles.work.roots[0].path"},
+		{
+			name:    "every problem at once",
+			data:    `{"editor": " ", "backups": {"retention_days": -1}}`,
+			wantErr: "auto-detect\nbackups.retention_days cannot be negative",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := parse([]byte(tt.data))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("err = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDeprecatedBaseDir(t *testing.T) {
+	a, b := t.TempDir(), t.TempDir()
+	tests := []struct {
+		name      string
+		data      string
+		set       string // Value for base_dir; "" unsets it
+		wantRoots []string
+	}{
+		{name: "set on empty file", set: a, wantRoots: []string{a}},
+		{name: "replaces the first root", data: `{"version": 2, "roots": [{"path": "/x"}, {"path": "/y"}]}`, set: a, wantRoots: []string{a, "/y"}},
+		{name: "moves an existing root first", data: `{"version": 2, "roots": [{"path": "` + a + `"}, {"path": "` + b + `"}]}`, set: b, wantRoots: []string{b, a}},
+		{name: "unset removes the first root", data: `{"version": 2, "roots": [{"path": "/x"}, {"path": "/y"}]}`, wantRoots: []string{"/y"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTempConfig(t, tt.data)
+			if tt.set != "" {
+				if _, err := Set("base_dir", tt.set, Scope{}); err != nil {
+					t.Fatal(err)
+				}
+			} else if err := Unset("base_dir", Scope{}); err != nil {
+				t.Fatal(err)
+			}
+			if got := RootPaths(); !reflect.DeepEqual(got, tt.wantRoots) {
+				t.Errorf("roots = %v, want %v", got, tt.wantRoots)
+			}
+			want := ""
+			if len(tt.wantRoots) > 0 {
+				want = tt.wantRoots[0]
+			}
+			if got, _, err := Value("base_dir", Scope{}); err != nil || got != want {
+				t.Errorf("base_dir = %q, %v; want %q", got, err, want)
+			}
+		})
+	}
+
+	k, err := LookupKey("base_dir")
+	if err != nil || k.Deprecated != "roots" {
+		t.Errorf("LookupKey(base_dir) = %+v, %v; want it deprecated in favour of roots", k, err)
+	}
+	for _, k := range Keys() {
+		if k.Name == "base_dir" {
+			t.Error("base_dir is listed in Keys")
+		}
+	}
+}
+
+func TestCurrentReadsOnce(t *testing.T) {
+	p := useTempConfig(t, `{"version": 2, "editor": "one"}`)
+	if got := current().Editor; got != "one" {
+		t.Fatalf("editor = %q, want one", got)
+	}
+
+	// Changes made behind treework's back aren't seen until it writes
+	if err := os.WriteFile(p, []byte(`{"version": 2, "editor": "two"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := current().Editor; got != "one" {
+		t.Errorf("editor = %q after an outside edit, want the cached one", got)
+	}
+
+	if err := Update(func(cfg *Config) error { cfg.Editor = "three"; return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if got := current().Editor; got != "three" {
+		t.Errorf("editor = %q after Update, want three", got)
+	}
+}
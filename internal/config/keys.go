@@ -0,0 +1,801 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Key describes a setting that can be read and written by name,
+// e.g. with 'treework config set editor code'.
+type Key struct {
+	Name        string
+	Description string
+	Env         string // Environment variable that overrides the file value, if any
+	Default     string // Shown as the source when nothing is set
+	PerRoot     bool   // Can be set on a single base folder with --root
+	Deprecated  string // Key to use instead, for old keys kept working for scripts
+
+	level     keyLevel
+	get       func(t *target) string
+	set       func(t *target, value string) error
+	normalize func(value string) (string, error)
+}
+
+// keyLevel is where in the config file a key lives.
+type keyLevel int
+
+const (
+	levelFile     keyLevel = iota // Top level only (not per profile)
+	levelSettings                 // Top level or per profile
+	levelOptions                  // Top level, per profile or per root
+)
+
+// Scope picks which part of the config file a key is read from or written to.
+type Scope struct {
+	Profile string // Profile name, or "" for the top level
+	Root    string // Base folder path, or "" for profile/top-level options
+}
+
+// target points at the parts of a loaded config selected by a Scope.
+type target struct {
+	cfg      *Config
+	settings *Settings
+	options  *Options
+}
+
+var keys = []Key{
+	{
+		Name:        "roots",
+		Description: "Folders where your git repos live (comma-separated)",
+		Env:         "DEV_DIR",
+		Default:     "not set",
+		level:       levelSettings,
+		get: func(t *target) string {
+			var paths []string
+			for _, r := range t.settings.Roots {
+				paths = append(paths, r.Path)
+			}
+			return strings.Join(paths, ",")
+		},
+		set:       setRoots,
+		normalize: normalizeDirList,
+	},
+	{
+		Name:        "favorite_repos",
+		Description: "Repos pinned to the top of the repo picker (comma-separated paths)",
+		Default:     "none",
+		level:       levelSettings,
+		get:         func(t *target) string { return strings.Join(t.settings.FavoriteRepos, ",") },
+		set: func(t *target, v string) error {
+			t.settings.FavoriteRepos = nil
+			if v != "" {
+				t.settings.FavoriteRepos = strings.Split(v, ",")
+			}
+			return nil
+		},
+		normalize: normalizeDirList,
+	},
+	{
+		Name:        "repo_aliases",
+		Description: "Short names for --repo (comma-separated name=path pairs, e.g. api=~/work/api-server)",
+		Default:     "none",
+		level:       levelSettings,
+		get:         func(t *target) string { return formatAliases(t.settings.RepoAliases) },
+		set: func(t *target, v string) error {
+			t.settings.RepoAliases = parseAliases(v)
+			return nil
+		},
+		normalize: normalizeAliases,
+	},
+	{
+		Name:        "editor",
+		Description: "Command used to open worktrees",
+		Env:         "WT_EDITOR",
+		Default:     "auto-detect",
+		PerRoot:     true,
+		level:       levelOptions,
+		get:         func(t *target) string { return t.options.Editor },
+		set:         func(t *target, v string) error { t.options.Editor = v; return nil },
+		normalize:   normalizeEditor,
+	},
+	{
+		Name:        "layout",
+		Description: "Worktree folder template, relative to the repo's parent folder",
+		Default:     DefaultLayout,
+		PerRoot:     true,
+		level:       levelOptions,
+		get:         func(t *target) string { return t.options.Layout },
+		set:         func(t *target, v string) error { t.options.Layout = v; return nil },
+		normalize: func(v string) (string, error) {
+			v = strings.TrimSpace(v)
+			return v, ValidateLayout(v)
+		},
+	},
+	{
+		Name:        "post_create",
+		Description: "Shell command run inside a new worktree",
+		Default:     "none",
+		PerRoot:     true,
+		level:       levelOptions,
+		get:         func(t *target) string { return hooks(t).PostCreate },
+		set:         func(t *target, v string) error { setHook(t, func(h *Hooks) { h.PostCreate = v }); return nil },
+		normalize:   normalizeCommand,
+	},
+	{
+		Name:        "pre_remove",
+		Description: "Shell command run inside a worktree before it is removed",
+		Default:     "none",
+		PerRoot:     true,
+		level:       levelOptions,
+		get:         func(t *target) string { return hooks(t).PreRemove },
+		set:         func(t *target, v string) error { setHook(t, func(h *Hooks) { h.PreRemove = v }); return nil },
+		normalize:   normalizeCommand,
+	},
+	{
+		Name:        "backup_retention_days",
+		Description: "Days to keep backups of removed worktrees",
+		Default:     strconv.Itoa(DefaultBackupRetentionDays),
+		PerRoot:     true,
+		level:       levelOptions,
+		get:         func(t *target) string { return itoa(backups(t).RetentionDays) },
+		set: func(t *target, v string) error {
+			setBackups(t, func(b *Backups) { b.RetentionDays = atoi(v) })
+			return nil
+		},
+		normalize: normalizeCount,
+	},
+	{
+		Name:        "backup_keep",
+		Description: "Most backups kept per worktree",
+		Default:     strconv.Itoa(DefaultBackupKeep),
+		PerRoot:     true,
+		level:       levelOptions,
+		get:         func(t *target) string { return itoa(backups(t).Keep) },
+		set: func(t *target, v string) error {
+			setBackups(t, func(b *Backups) { b.Keep = atoi(v) })
+			return nil
+		},
+		normalize: normalizeCount,
+	},
+	{
+		Name:        "protected_branches",
+		Description: "Branches never deleted or checked out by new (comma-separated globs, e.g. release/*)",
+		Default:     strings.Join(DefaultProtectedBranches, ","),
+		PerRoot:     true,
+		level:       levelOptions,
+		get:         func(t *target) string { return strings.Join(t.options.ProtectedBranches, ",") },
+		set: func(t *target, v string) error {
+			t.options.ProtectedBranches = nil
+			if v != "" {
+				t.options.ProtectedBranches = strings.Split(v, ",")
+			}
+			return nil
+		},
+		normalize: normalizePatterns,
+	},
+	{
+		Name:        "remote",
+		Description: "Remote whose default branch merged checks compare against",
+		Default:     "upstream, else origin",
+		PerRoot:     true,
+		level:       levelOptions,
+		get:         func(t *target) string { return merge(t).Remote },
+		set: func(t *target, v string) error {
+			setMerge(t, func(m *Merge) { m.Remote = v })
+			return nil
+		},
+		normalize: normalizeName,
+	},
+	{
+		Name:        "default_branch",
+		Description: "Branch merged checks compare against",
+		Default:     "detected from the remote",
+		PerRoot:     true,
+		level:       levelOptions,
+		get:         func(t *target) string { return merge(t).DefaultBranch },
+		set: func(t *target, v string) error {
+			setMerge(t, func(m *Merge) { m.DefaultBranch = v })
+			return nil
+		},
+		normalize: normalizeName,
+	},
+	{
+		Name:        "fetch_before_merge_check",
+		Description: "Fetch the default branch before checking whether branches are merged",
+		Default:     "false",
+		PerRoot:     true,
+		level:       levelOptions,
+		get:         func(t *target) string { return formatBool(merge(t).Fetch) },
+		set: func(t *target, v string) error {
+			setMerge(t, func(m *Merge) { m.Fetch = parseBool(v) })
+			return nil
+		},
+		normalize: normalizeBool,
+	},
+	{
+		Name:        "scan_depth",
+		Description: "How many folders below a base folder to look for repos",
+		Default:     strconv.Itoa(DefaultScanDepth),
+		PerRoot:     true,
+		level:       levelOptions,
+		get:         func(t *target) string { return itoa(scan(t).Depth) },
+		set: func(t *target, v string) error {
+			setScan(t, func(s *Scan) { s.Depth = atoi(v) })
+			return nil
+		},
+		normalize: normalizeCount,
+	},
+	{
+		Name:        "scan_ignore",
+		Description: "Folders not searched for repos (comma-separated globs, e.g. node_modules,archive/*)",
+		Default:     strings.Join(DefaultScanIgnore, ","),
+		PerRoot:     true,
+		level:       levelOptions,
+		get:         func(t *target) string { return strings.Join(scan(t).Ignore, ",") },
+		set: func(t *target, v string) error {
+			setScan(t, func(s *Scan) {
+				s.Ignore = nil
+				if v != "" {
+					s.Ignore = strings.Split(v, ",")
+				}
+			})
+			return nil
+		},
+		normalize: normalizePatterns,
+	},
+	{
+		Name:        "scan_nested",
+		Description: "Look for repos inside other repos (e.g. clones kept in a repo's folder)",
+		Default:     "false",
+		PerRoot:     true,
+		level:       levelOptions,
+		get:         func(t *target) string { return formatBool(scan(t).Nested) },
+		set: func(t *target, v string) error {
+			setScan(t, func(s *Scan) { s.Nested = parseBool(v) })
+			return nil
+		},
+		normalize: normalizeBool,
+	},
+	{
+		Name:        "profile",
+		Description: "Profile used when --profile and TREEWORK_PROFILE are not set",
+		Env:         "TREEWORK_PROFILE",
+		Default:     "none",
+		level:       levelFile,
+		get:         func(t *target) string { return t.cfg.Profile },
+		set: func(t *target, v string) error {
+			if _, ok := t.cfg.Profiles[v]; v != "" && !ok {
+				return fmt.Errorf("profile '%s' is not defined — set a key with --profile %s first", v, v)
+			}
+			t.cfg.Profile = v
+			return nil
+		},
+		normalize: func(v string) (string, error) { return strings.TrimSpace(v), nil },
+	},
+}
+
+// deprecatedKeys still work with get, set and unset but aren't listed.
+var deprecatedKeys = []Key{
+	{
+		Name:        "base_dir",
+		Description: "First of the folders in roots",
+		Env:         "DEV_DIR",
+		Default:     "not set",
+		Deprecated:  "roots",
+		level:       levelSettings,
+		get: func(t *target) string {
+			if len(t.settings.Roots) == 0 {
+				return ""
+			}
+			return t.settings.Roots[0].Path
+		},
+		set:       setFirstRoot,
+		normalize: NormalizeDir,
+	},
+}
+
+// Keys returns every settable config key in display order.
+func Keys() []Key {
+	return keys
+}
+
+// LookupKey finds a config key by name.
+func LookupKey(name string) (Key, error) {
+	for _, k := range append(keys, deprecatedKeys...) {
+		if k.Name == name {
+			return k, nil
+		}
+	}
+	var names []string
+	for _, k := range keys {
+		names = append(names, k.Name)
+	}
+	return Key{}, fmt.Errorf("unknown config key '%s' (valid keys: %s)", name, strings.Join(names, ", "))
+}
+
+// Value returns the effective value of a key within scope and a human-readable
+// source description. Priority: env var > root > profile > config file > default.
+func Value(name string, scope Scope) (value, source string, err error) {
+	k, err := LookupKey(name)
+	if err != nil {
+		return "", "", err
+	}
+	if k.Env != "" {
+		if v := os.Getenv(k.Env); v != "" {
+			return v, k.Env + " env var", nil
+		}
+	}
+
+	cfg := current()
+	if k.level == levelFile {
+		if v := k.get(&target{cfg: cfg}); v != "" {
+			return v, "config file", nil
+		}
+		return "", k.Default, nil
+	}
+
+	if scope.Root != "" && k.level == levelOptions {
+		if t, err := resolveTarget(cfg, rootScope(cfg, scope), false); err == nil {
+			if v := k.get(t); v != "" {
+				return v, "base folder " + t.settings.Roots[rootIndex(t.settings, scope.Root)].Path, nil
+			}
+		}
+	}
+	if scope.Profile != "" {
+		if t, err := resolveTarget(cfg, Scope{Profile: scope.Profile}, false); err == nil {
+			if v := k.get(t); v != "" {
+				return v, fmt.Sprintf("profile '%s'", scope.Profile), nil
+			}
+		}
+	}
+	t, _ := resolveTarget(cfg, Scope{}, false)
+	if v := k.get(t); v != "" {
+		return v, "config file", nil
+	}
+	return "", k.Default, nil
+}
+
+// Set validates a value and writes it to the config file at scope, creating
+// the profile if needed. Returns the value as stored (paths are expanded and
+// made absolute).
+func Set(name, value string, scope Scope) (string, error) {
+	k, err := LookupKey(name)
+	if err != nil {
+		return "", err
+	}
+	if err := k.checkScope(scope); err != nil {
+		return "", err
+	}
+	if k.level == levelFile {
+		scope.Profile = ""
+	}
+	value, err = k.normalize(value)
+	if err != nil {
+		return "", err
+	}
+	return value, Update(func(cfg *Config) error {
+		scope := rootScope(cfg, scope)
+		t, err := resolveTarget(cfg, scope, true)
+		if err != nil {
+			return err
+		}
+		if err := k.set(t, value); err != nil {
+			return err
+		}
+		commit(cfg, scope, t)
+		return nil
+	})
+}
+
+// Unset removes a key at scope so the next level (or the default) applies again.
+func Unset(name string, scope Scope) error {
+	k, err := LookupKey(name)
+	if err != nil {
+		return err
+	}
+	if err := k.checkScope(scope); err != nil {
+		return err
+	}
+	if k.level == levelFile {
+		scope.Profile = ""
+	}
+	return Update(func(cfg *Config) error {
+		scope := rootScope(cfg, scope)
+		t, err := resolveTarget(cfg, scope, false)
+		if err != nil {
+			return err
+		}
+		if err := k.set(t, ""); err != nil {
+			return err
+		}
+		commit(cfg, scope, t)
+		return nil
+	})
+}
+
+// checkScope rejects scopes a key can't be stored at.
+func (k Key) checkScope(scope Scope) error {
+	if scope.Root != "" && !k.PerRoot {
+		return fmt.Errorf("%s can't be set per base folder", k.Name)
+	}
+	if scope.Root != "" && k.level != levelOptions {
+		return fmt.Errorf("%s can't be set per base folder", k.Name)
+	}
+	return nil
+}
+
+// resolveTarget finds the settings and options selected by scope. Profiles
+// are copied out of the map; call commit to write changes back.
+func resolveTarget(cfg *Config, scope Scope, create bool) (*target, error) {
+	t := &target{cfg: cfg, settings: &cfg.Settings}
+	if scope.Profile != "" {
+		p, ok := cfg.Profiles[scope.Profile]
+		if !ok && !create {
+			return nil, fmt.Errorf("unknown profile '%s'", scope.Profile)
+		}
+		t.settings = &p
+	}
+	t.options = &t.settings.Options
+
+	if scope.Root != "" {
+		i := rootIndex(t.settings, scope.Root)
+		if i < 0 {
+			return nil, fmt.Errorf("'%s' is not one of the configured base folders", scope.Root)
+		}
+		t.options = &t.settings.Roots[i].Options
+	}
+	return t, nil
+}
+
+// rootScope points a per-root scope at whichever level owns the root: the
+// profile if it lists its own roots, otherwise the top level.
+func rootScope(cfg *Config, scope Scope) Scope {
+	if scope.Root == "" || scope.Profile == "" {
+		return scope
+	}
+	if p, ok := cfg.Profiles[scope.Profile]; ok && rootIndex(&p, scope.Root) >= 0 {
+		return scope
+	}
+	return Scope{Root: scope.Root}
+}
+
+// commit writes a profile copied out by resolveTarget back into the config.
+func commit(cfg *Config, scope Scope, t *target) {
+	if scope.Profile == "" {
+		return
+	}
+	if cfg.Profiles == nil {
+		cfg.Profiles = make(map[string]Settings)
+	}
+	cfg.Profiles[scope.Profile] = *t.settings
+}
+
+// rootIndex finds a root by path, accepting ~ and relative paths.
+func rootIndex(s *Settings, path string) int {
+	want := expandPath(path)
+	for i, r := range s.Roots {
+		if filepath.Clean(r.Path) == want {
+			return i
+		}
+	}
+	return -1
+}
+
+// setRoots replaces the list of roots, keeping per-root options for paths
+// that were already configured.
+func setRoots(t *target, value string) error {
+	var roots []Root
+	for _, p := range strings.Split(value, ",") {
+		if p == "" {
+			continue
+		}
+		root := Root{Path: p}
+		if i := rootIndex(t.settings, p); i >= 0 {
+			root.Options = t.settings.Roots[i].Options
+		}
+		roots = append(roots, root)
+	}
+	t.settings.Roots = roots
+	return nil
+}
+
+// setFirstRoot makes path the first base folder, as base_dir did before
+// there could be several. An empty path removes the first one.
+func setFirstRoot(t *target, path string) error {
+	roots := t.settings.Roots
+	if path == "" {
+		if len(roots) > 0 {
+			t.settings.Roots = roots[1:]
+		}
+		return nil
+	}
+	first := Root{Path: path}
+	if i := rootIndex(t.settings, path); i >= 0 {
+		first = roots[i]
+		roots = append(roots[:i:i], roots[i+1:]...)
+	} else if len(roots) > 0 {
+		roots = roots[1:] // base_dir replaced the folder
+	}
+	t.settings.Roots = append([]Root{first}, roots...)
+	return nil
+}
+
+func hooks(t *target) Hooks {
+	if t.options.Hooks == nil {
+		return Hooks{}
+	}
+	return *t.options.Hooks
+}
+
+func setHook(t *target, fn func(h *Hooks)) {
+	h := hooks(t)
+	fn(&h)
+	if h == (Hooks{}) {
+		t.options.Hooks = nil
+		return
+	}
+	t.options.Hooks = &h
+}
+
+func backups(t *target) Backups {
+	if t.options.Backups == nil {
+		return Backups{}
+	}
+	return *t.options.Backups
+}
+
+func setBackups(t *target, fn func(b *Backups)) {
+	b := backups(t)
+	fn(&b)
+	if b == (Backups{}) {
+		t.options.Backups = nil
+		return
+	}
+	t.options.Backups = &b
+}
+
+func merge(t *target) Merge {
+	if t.options.Merge == nil {
+		return Merge{}
+	}
+	return *t.options.Merge
+}
+
+func setMerge(t *target, fn func(m *Merge)) {
+	m := merge(t)
+	fn(&m)
+	if m == (Merge{}) {
+		t.options.Merge = nil
+		return
+	}
+	t.options.Merge = &m
+}
+
+func scan(t *target) Scan {
+	if t.options.Scan == nil {
+		return Scan{}
+	}
+	return *t.options.Scan
+}
+
+func setScan(t *target, fn func(s *Scan)) {
+	s := scan(t)
+	fn(&s)
+	if s.Depth == 0 && len(s.Ignore) == 0 && s.Nested == nil {
+		t.options.Scan = nil
+		return
+	}
+	t.options.Scan = &s
+}
+
+// formatBool formats an optional flag for display, with nil meaning unset.
+func formatBool(b *bool) string {
+	if b == nil {
+		return ""
+	}
+	return strconv.FormatBool(*b)
+}
+
+// parseBool parses a flag already checked by normalizeBool; "" (unset) is nil.
+func parseBool(s string) *bool {
+	if s == "" {
+		return nil
+	}
+	b := s == "true"
+	return &b
+}
+
+// itoa formats a count for display, with 0 meaning unset.
+func itoa(n int) string {
+	if n == 0 {
+		return ""
+	}
+	return strconv.Itoa(n)
+}
+
+// atoi parses a count already checked by normalizeCount; "" (unset) is 0.
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+// expandPath expands a leading ~ and makes path absolute and clean.
+func expandPath(path string) string {
+	path = strings.TrimSpace(path)
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, strings.TrimPrefix(path, "~"))
+		}
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return filepath.Clean(path)
+}
+
+// NormalizeDir expands ~, makes the path absolute and checks it is an existing directory.
+func NormalizeDir(value string) (string, error) {
+	if strings.TrimSpace(value) == "" {
+		return "", fmt.Errorf("path cannot be empty")
+	}
+	abs := expandPath(value)
+	info, err := os.Stat(abs)
+	if err != nil {
+		return "", fmt.Errorf("'%s' does not exist", abs)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("'%s' is not a directory", abs)
+	}
+	return abs, nil
+}
+
+// normalizeDirList normalizes a comma-separated list of directories.
+func normalizeDirList(value string) (string, error) {
+	var dirs []string
+	seen := make(map[string]bool)
+	for _, p := range strings.Split(value, ",") {
+		if strings.TrimSpace(p) == "" {
+			continue
+		}
+		dir, err := NormalizeDir(p)
+		if err != nil {
+			return "", err
+		}
+		if !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+	if len(dirs) == 0 {
+		return "", fmt.Errorf("at least one folder is required — use 'unset' to clear")
+	}
+	return strings.Join(dirs, ","), nil
+}
+
+// normalizeAliases checks each name=path pair of a repo_aliases list and
+// makes the paths absolute.
+func normalizeAliases(value string) (string, error) {
+	aliases := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		name, dir, ok := strings.Cut(pair, "=")
+		if !ok {
+			return "", fmt.Errorf("'%s' should be name=path", strings.TrimSpace(pair))
+		}
+		name = strings.TrimSpace(name)
+		if err := ValidateAlias(name); err != nil {
+			return "", err
+		}
+		dir, err := NormalizeDir(strings.TrimSpace(dir))
+		if err != nil {
+			return "", err
+		}
+		aliases[name] = dir
+	}
+	if len(aliases) == 0 {
+		return "", fmt.Errorf("at least one name=path pair is required — use 'unset' to clear")
+	}
+	return formatAliases(aliases), nil
+}
+
+// formatAliases writes aliases as a name=path list, sorted by name.
+func formatAliases(aliases map[string]string) string {
+	var pairs []string
+	for name, dir := range aliases {
+		pairs = append(pairs, name+"="+dir)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func parseAliases(value string) map[string]string {
+	if value == "" {
+		return nil
+	}
+	aliases := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		if name, dir, ok := strings.Cut(pair, "="); ok {
+			aliases[name] = dir
+		}
+	}
+	return aliases
+}
+
+// normalizeEditor checks that the editor command can be found on $PATH.
+func normalizeEditor(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return "", fmt.Errorf("value cannot be empty — use 'unset' to auto-detect")
+	}
+	if _, err := exec.LookPath(fields[0]); err != nil {
+		return "", fmt.Errorf("'%s' not found on your PATH", fields[0])
+	}
+	return value, nil
+}
+
+// normalizeCommand checks a hook command is not blank.
+func normalizeCommand(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", fmt.Errorf("value cannot be empty — use 'unset' to remove the hook")
+	}
+	return value, nil
+}
+
+// normalizeName checks a remote or branch name is a single word.
+func normalizeName(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || strings.ContainsAny(value, " \t") {
+		return "", fmt.Errorf("'%s' must be a single name without spaces", value)
+	}
+	return value, nil
+}
+
+// normalizePatterns checks a comma-separated list of branch glob patterns.
+func normalizePatterns(value string) (string, error) {
+	var patterns []string
+	for _, p := range strings.Split(value, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if _, err := path.Match(p, ""); err != nil {
+			return "", fmt.Errorf("'%s' is not a valid pattern", p)
+		}
+		patterns = append(patterns, p)
+	}
+	if len(patterns) == 0 {
+		return "", fmt.Errorf("at least one pattern is required — use 'unset' to go back to the default")
+	}
+	return strings.Join(patterns, ","), nil
+}
+
+// normalizeBool accepts true/false, yes/no, on/off and 1/0.
+func normalizeBool(value string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "true", "yes", "on", "1":
+		return "true", nil
+	case "false", "no", "off", "0":
+		return "false", nil
+	}
+	return "", fmt.Errorf("'%s' must be true or false", value)
+}
+
+// normalizeCount checks a value is a whole number greater than zero.
+func normalizeCount(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return "", fmt.Errorf("'%s' must be a whole number greater than 0", value)
+	}
+	return strconv.Itoa(n), nil
+}
@@ -0,0 +1,270 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// profileOverride is the profile chosen with --profile for this run.
+var profileOverride string
+
+// UseProfile selects a profile for the rest of this run (the --profile flag).
+func UseProfile(name string) {
+	profileOverride = name
+}
+
+// ActiveProfile returns the selected profile name, or "" for the top-level settings.
+// Priority: --profile flag > TREEWORK_PROFILE env var > profile key in the config file.
+func ActiveProfile() string {
+	if profileOverride != "" {
+		return profileOverride
+	}
+	if p := os.Getenv("TREEWORK_PROFILE"); p != "" {
+		return p
+	}
+	return current().Profile
+}
+
+// ProfileNames returns the names of all defined profiles, sorted.
+func ProfileNames() []string {
+	var names []string
+	for name := range current().Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CheckProfile returns an error if the active profile isn't defined in the config file.
+func CheckProfile() error {
+	name := ActiveProfile()
+	if name == "" {
+		return nil
+	}
+	if _, ok := current().Profiles[name]; ok {
+		return nil
+	}
+	if names := ProfileNames(); len(names) > 0 {
+		return fmt.Errorf("unknown profile '%s' (defined: %s)", name, strings.Join(names, ", "))
+	}
+	return fmt.Errorf("unknown profile '%s' — no profiles are defined yet", name)
+}
+
+// layers returns the settings that apply to this run, most specific first:
+// the active profile (if any), then the top level.
+func layers(cfg *Config) []Settings {
+	if p, ok := cfg.Profiles[ActiveProfile()]; ok {
+		return []Settings{p, cfg.Settings}
+	}
+	return []Settings{cfg.Settings}
+}
+
+// Roots returns the base folders for this run.
+// Priority: DEV_DIR env var (paths separated by ':') > profile > config file.
+func Roots() []Root {
+	if d := os.Getenv("DEV_DIR"); d != "" {
+		var roots []Root
+		for _, p := range filepath.SplitList(d) {
+			if p != "" {
+				roots = append(roots, Root{Path: p})
+			}
+		}
+		return roots
+	}
+	for _, s := range layers(current()) {
+		if len(s.Roots) > 0 {
+			return s.Roots
+		}
+	}
+	return nil
+}
+
+// RootPaths returns the paths of Roots.
+func RootPaths() []string {
+	var paths []string
+	for _, r := range Roots() {
+		paths = append(paths, r.Path)
+	}
+	return paths
+}
+
+// RootFor returns the base folder containing path. When roots are nested the
+// deepest one wins.
+func RootFor(path string) (Root, bool) {
+	var best Root
+	found := false
+	for _, r := range Roots() {
+		rel, err := filepath.Rel(r.Path, path)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		if !found || len(r.Path) > len(best.Path) {
+			best, found = r, true
+		}
+	}
+	return best, found
+}
+
+// FavoriteRepos returns the repos pinned to the top of the repo picker, in
+// the order they were pinned. The profile's list, if set, replaces the top
+// level's.
+func FavoriteRepos() []string {
+	for _, s := range layers(current()) {
+		if len(s.FavoriteRepos) > 0 {
+			return s.FavoriteRepos
+		}
+	}
+	return nil
+}
+
+// RepoAliases returns the short names --repo accepts, mapped to repo paths.
+// The profile's aliases, if set, replace the top level's.
+func RepoAliases() map[string]string {
+	for _, s := range layers(current()) {
+		if len(s.RepoAliases) > 0 {
+			return s.RepoAliases
+		}
+	}
+	return nil
+}
+
+// option resolves one option for path: root > profile > top level.
+// An empty path skips the per-root lookup.
+func option(path string, get func(Options) string) string {
+	if path != "" {
+		if r, ok := RootFor(path); ok {
+			if v := get(r.Options); v != "" {
+				return v
+			}
+		}
+	}
+	for _, s := range layers(current()) {
+		if v := get(s.Options); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// Editor returns the preferred editor command.
+// Priority: WT_EDITOR env var > profile > config file > "" (auto-detect).
+func Editor() string {
+	return EditorFor("")
+}
+
+// EditorFor returns the editor for a repo or worktree path, honoring the
+// editor set on the base folder that contains it.
+func EditorFor(path string) string {
+	if e := os.Getenv("WT_EDITOR"); e != "" {
+		return e
+	}
+	return option(path, func(o Options) string { return o.Editor })
+}
+
+// EditorSource returns the current editor and a human-readable source description.
+func EditorSource() (editor, source string) {
+	editor, source, _ = Value("editor", Scope{Profile: ActiveProfile()})
+	return editor, source
+}
+
+// LayoutFor returns the worktree folder template for a repo.
+func LayoutFor(repoDir string) string {
+	if l := option(repoDir, func(o Options) string { return o.Layout }); l != "" {
+		return l
+	}
+	return DefaultLayout
+}
+
+// HooksFor returns the hooks for a repo or worktree path. Each hook is
+// resolved on its own, so a root can override just one of them.
+func HooksFor(path string) Hooks {
+	hook := func(get func(Hooks) string) func(Options) string {
+		return func(o Options) string {
+			if o.Hooks == nil {
+				return ""
+			}
+			return get(*o.Hooks)
+		}
+	}
+	return Hooks{
+		PostCreate: option(path, hook(func(h Hooks) string { return h.PostCreate })),
+		PreRemove:  option(path, hook(func(h Hooks) string { return h.PreRemove })),
+	}
+}
+
+// BackupRetentionFor returns how many days and how many backups per worktree
+// to keep for a repo. Each value is resolved on its own.
+func BackupRetentionFor(path string) (days, keep int) {
+	backup := func(get func(Backups) int) func(Options) string {
+		return func(o Options) string {
+			if o.Backups == nil || get(*o.Backups) == 0 {
+				return ""
+			}
+			return strconv.Itoa(get(*o.Backups))
+		}
+	}
+	days, _ = strconv.Atoi(option(path, backup(func(b Backups) int { return b.RetentionDays })))
+	keep, _ = strconv.Atoi(option(path, backup(func(b Backups) int { return b.Keep })))
+	if days == 0 {
+		days = DefaultBackupRetentionDays
+	}
+	if keep == 0 {
+		keep = DefaultBackupKeep
+	}
+	return days, keep
+}
+
+// MergeCheckFor returns the remote and default branch merged checks should
+// compare against for a repo ("" means detect it) and whether to fetch first.
+func MergeCheckFor(path string) (remote, defaultBranch string, fetch bool) {
+	get := func(fn func(Merge) string) func(Options) string {
+		return func(o Options) string {
+			if o.Merge == nil {
+				return ""
+			}
+			return fn(*o.Merge)
+		}
+	}
+	remote = option(path, get(func(m Merge) string { return m.Remote }))
+	defaultBranch = option(path, get(func(m Merge) string { return m.DefaultBranch }))
+	fetch = option(path, get(func(m Merge) string { return formatBool(m.Fetch) })) == "true"
+	return remote, defaultBranch, fetch
+}
+
+// ProtectedBranchesFor returns the branch patterns that must never be deleted
+// in a repo. The most specific level that sets the list wins; lists aren't merged.
+func ProtectedBranchesFor(path string) []string {
+	list := option(path, func(o Options) string { return strings.Join(o.ProtectedBranches, ",") })
+	if list == "" {
+		return DefaultProtectedBranches
+	}
+	return strings.Split(list, ",")
+}
+
+// ScanFor returns how a base folder is searched for repos: how many folders
+// deep, which folders to skip and whether to look inside repos. Each value
+// is resolved on its own.
+func ScanFor(root string) (depth int, ignore []string, nested bool) {
+	get := func(fn func(Scan) string) func(Options) string {
+		return func(o Options) string {
+			if o.Scan == nil {
+				return ""
+			}
+			return fn(*o.Scan)
+		}
+	}
+	depth, _ = strconv.Atoi(option(root, get(func(s Scan) string { return itoa(s.Depth) })))
+	if depth == 0 {
+		depth = DefaultScanDepth
+	}
+	ignore = DefaultScanIgnore
+	if list := option(root, get(func(s Scan) string { return strings.Join(s.Ignore, ",") })); list != "" {
+		ignore = strings.Split(list, ",")
+	}
+	nested = option(root, get(func(s Scan) string { return formatBool(s.Nested) })) == "true"
+	return depth, ignore, nested
+}
@@ -2,14 +2,15 @@ package editor
 
 import (
 	"os/exec"
+	"strings"
 
 	"github.com/vanderhaka/treework/internal/config"
 )
 
 // Open opens the given path in the preferred editor.
-// Priority: WT_EDITOR env var > cursor > code > Finder.
+// Priority: WT_EDITOR env var > configured editor (base folder, profile, global) > cursor > code > Finder.
 func Open(path string) error {
-	if ed := config.Editor(); ed != "" {
+	if ed := config.EditorFor(path); ed != "" {
 		return run(ed, path)
 	}
 
@@ -37,6 +38,8 @@ func run(editor, path string) error {
 	case "code":
 		return exec.Command(editor, "-n", path).Start()
 	default:
-		return exec.Command(editor, path).Start()
+		// Custom commands may carry their own flags, e.g. "subl -n"
+		fields := strings.Fields(editor)
+		return exec.Command(fields[0], append(fields[1:], path)...).Start()
 	}
 }
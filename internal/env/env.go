@@ -7,6 +7,21 @@ import (
 	"strings"
 )
 
+// List returns the names of the .env* files in dir.
+func List(dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasPrefix(entry.Name(), ".env") {
+			names = append(names, entry.Name())
+		}
+	}
+	return names
+}
+
 // CopyEnvFiles copies .env* files from src to dst, skipping files that already exist in dst.
 func CopyEnvFiles(srcDir, dstDir string) ([]string, error) {
 	entries, err := os.ReadDir(srcDir)
@@ -0,0 +1,98 @@
+// Package frecency remembers which repos treework was used with, and when,
+// so repo pickers can list the ones used most often and most recently first.
+package frecency
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/vanderhaka/treework/internal/config"
+	"github.com/vanderhaka/treework/internal/fileutil"
+)
+
+// maxTotal caps the sum of all counts. Past it every count is scaled down,
+// so repos that stop being used drift down the list and are eventually
+// forgotten.
+const maxTotal = 1000
+
+type entry struct {
+	Count float64   `json:"count"`
+	Last  time.Time `json:"last"`
+}
+
+// Path returns the file uses are recorded in.
+func Path() string {
+	return filepath.Join(config.StateDir(), "repos-used.json")
+}
+
+// Record notes that repo was used now. The record is only a ranking hint,
+// so failures are ignored.
+func Record(repo string) {
+	unlock, err := fileutil.Lock(Path())
+	if err != nil {
+		return
+	}
+	defer unlock()
+
+	entries := load()
+	e := entries[repo]
+	e.Count++
+	e.Last = time.Now().UTC()
+	entries[repo] = e
+
+	total := 0.0
+	for _, e := range entries {
+		total += e.Count
+	}
+	if total > maxTotal {
+		for r, e := range entries {
+			e.Count *= 0.9
+			if e.Count < 1 {
+				delete(entries, r)
+				continue
+			}
+			entries[r] = e
+		}
+	}
+
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return
+	}
+	fileutil.WriteAtomic(Path(), data, 0o644)
+}
+
+// Scores returns each used repo's score: how often it was used, weighted by
+// how recently. Repos never used aren't included.
+func Scores() map[string]float64 {
+	scores := make(map[string]float64)
+	now := time.Now()
+	for repo, e := range load() {
+		scores[repo] = e.Count * weight(now.Sub(e.Last))
+	}
+	return scores
+}
+
+// weight favours recent use: a repo used a lot last month ranks below one
+// used a few times today.
+func weight(age time.Duration) float64 {
+	switch {
+	case age < time.Hour:
+		return 4
+	case age < 24*time.Hour:
+		return 2
+	case age < 7*24*time.Hour:
+		return 0.5
+	}
+	return 0.25
+}
+
+func load() map[string]entry {
+	entries := make(map[string]entry)
+	if data, err := os.ReadFile(Path()); err == nil {
+		json.Unmarshal(data, &entries)
+	}
+	return entries
+}
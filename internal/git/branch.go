@@ -1,7 +1,10 @@
 package git
 
 import (
+	"fmt"
 	"os/exec"
+	"path"
+	"slices"
 	"strings"
 )
 
@@ -11,36 +14,108 @@ func BranchExists(repoDir, branch string) bool {
 	return err == nil
 }
 
-// DefaultBranch returns the default branch for the repo (main or master).
+// DefaultBranch returns the default branch for the repo. In order: the
+// treework.defaultBranch git config, the default remote's HEAD, a local main
+// or master, then git's init.defaultBranch.
 func DefaultBranch(repoDir string) string {
-	// Try origin/HEAD first
-	out, err := exec.Command("git", "-C", repoDir, "symbolic-ref", "--quiet", "--short", "refs/remotes/origin/HEAD").Output()
-	if err == nil {
-		d := strings.TrimSpace(string(out))
-		d = strings.TrimPrefix(d, "origin/")
-		if d != "" {
-			return d
+	return DefaultBranchFor(repoDir, DefaultRemote(repoDir))
+}
+
+// DefaultBranchFor is DefaultBranch looking at a given remote's HEAD.
+func DefaultBranchFor(repoDir, remote string) string {
+	if b := configValue(repoDir, "treework.defaultBranch"); b != "" {
+		return b
+	}
+
+	if remote != "" {
+		out, err := exec.Command("git", "-C", repoDir, "symbolic-ref", "--quiet", "--short", "refs/remotes/"+remote+"/HEAD").Output()
+		if err == nil {
+			if d := strings.TrimPrefix(strings.TrimSpace(string(out)), remote+"/"); d != "" {
+				return d
+			}
+		}
+	}
+
+	for _, b := range []string{"main", "master"} {
+		if BranchExists(repoDir, b) {
+			return b
+		}
+	}
+
+	if b := configValue(repoDir, "init.defaultBranch"); b != "" {
+		return b
+	}
+	return "master" // git's own default when init.defaultBranch is unset
+}
+
+// DefaultRemote returns the remote whose default branch work lands in. In
+// order: the treework.remote git config, "upstream" (the original repo in a
+// fork workflow), "origin", then the only remote. Returns "" if there is none.
+func DefaultRemote(repoDir string) string {
+	if r := configValue(repoDir, "treework.remote"); r != "" {
+		return r
+	}
+	remotes := Remotes(repoDir)
+	for _, want := range []string{"upstream", "origin"} {
+		if slices.Contains(remotes, want) {
+			return want
 		}
 	}
+	if len(remotes) == 1 {
+		return remotes[0]
+	}
+	return ""
+}
+
+// Remotes returns the names of the repo's remotes.
+func Remotes(repoDir string) []string {
+	out, err := exec.Command("git", "-C", repoDir, "remote").Output()
+	if err != nil {
+		return nil
+	}
+	return strings.Fields(string(out))
+}
 
-	// Check for main
-	if err := exec.Command("git", "-C", repoDir, "show-ref", "--verify", "--quiet", "refs/heads/main").Run(); err == nil {
-		return "main"
+// FetchBranch updates the remote-tracking ref for one branch of a remote.
+func FetchBranch(repoDir, remote, branch string) error {
+	out, err := exec.Command("git", "-C", repoDir, "fetch", "--quiet", remote, branch).CombinedOutput()
+	if err != nil {
+		msg, _, _ := strings.Cut(strings.TrimSpace(string(out)), "\n")
+		return fmt.Errorf("%s", msg)
 	}
+	return nil
+}
 
-	// Check for master
-	if err := exec.Command("git", "-C", repoDir, "show-ref", "--verify", "--quiet", "refs/heads/master").Run(); err == nil {
-		return "master"
+// IsProtected reports whether branch matches one of patterns (globs such
+// as "release/*"), a treework.protectedBranch pattern in the repo's git config,
+// or is the repo's default branch.
+func IsProtected(repoDir, branch string, patterns []string) bool {
+	if branch == "" || branch == "HEAD" {
+		return false
 	}
+	out, _ := exec.Command("git", "-C", repoDir, "config", "--get-all", "treework.protectedBranch").Output()
+	patterns = append(slices.Clone(patterns), strings.Fields(string(out))...)
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, branch); ok {
+			return true
+		}
+	}
+	return branch == DefaultBranch(repoDir)
+}
 
-	return "main"
+// configValue reads a git config value, or "" if it isn't set.
+func configValue(repoDir, key string) string {
+	out, err := exec.Command("git", "-C", repoDir, "config", "--get", key).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
 }
 
-// IsBranchMerged checks if branch is merged into the default branch.
+// IsBranchMerged checks if branch is merged into the default branch by any
+// method, including squash and rebase merges. See BranchMergeStatus.
 func IsBranchMerged(repoDir, branch string) bool {
-	base := DefaultBranch(repoDir)
-	err := exec.Command("git", "-C", repoDir, "merge-base", "--is-ancestor", "--", branch, base).Run()
-	return err == nil
+	return BranchMergeStatus(repoDir, branch).Merged()
 }
 
 // DeleteBranch deletes a local branch (soft delete with -d).
@@ -52,3 +127,120 @@ func DeleteBranch(repoDir, branch string) error {
 func ForceDeleteBranch(repoDir, branch string) error {
 	return exec.Command("git", "-C", repoDir, "branch", "-D", "--", branch).Run()
 }
+
+// LocalBranches returns the short names of all local branches.
+func LocalBranches(repoDir string) []string {
+	out, err := exec.Command("git", "-C", repoDir, "for-each-ref", "--format=%(refname:short)", "refs/heads").Output()
+	if err != nil {
+		return nil
+	}
+	return strings.Fields(string(out))
+}
+
+// RemoteBranches returns remote branch names with the remote prefix stripped
+// (origin/feature-x → feature-x). Symbolic refs such as origin/HEAD are skipped.
+func RemoteBranches(repoDir string) []string {
+	out, err := exec.Command("git", "-C", repoDir, "for-each-ref", "--format=%(refname:strip=3) %(symref)", "refs/remotes").Output()
+	if err != nil {
+		return nil
+	}
+
+	var branches []string
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 1 {
+			continue // empty line or symbolic ref
+		}
+		branches = append(branches, fields[0])
+	}
+	return branches
+}
+
+// RemoteBranchExists checks if any remote has a branch with the given name.
+func RemoteBranchExists(repoDir, branch string) bool {
+	for _, b := range RemoteBranches(repoDir) {
+		if b == branch {
+			return true
+		}
+	}
+	return false
+}
+
+// BranchRemote picks the remote to check out a remote-only branch from: the
+// repo's default remote if it has the branch, otherwise the only remote that
+// does. Returns "" if no remote has it, or several do and none is the default.
+func BranchRemote(repoDir, branch string) string {
+	var has []string
+	for _, r := range Remotes(repoDir) {
+		if RevParse(repoDir, "refs/remotes/"+r+"/"+branch) != "" {
+			has = append(has, r)
+		}
+	}
+	if def := DefaultRemote(repoDir); slices.Contains(has, def) {
+		return def
+	}
+	if len(has) == 1 {
+		return has[0]
+	}
+	return ""
+}
+
+// RenameBranch renames a local branch, carrying its upstream config along.
+func RenameBranch(repoDir, from, to string) error {
+	out, err := exec.Command("git", "-C", repoDir, "branch", "-m", "--", from, to).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// Upstream returns the remote and remote branch a local branch tracks,
+// or empty strings if it has no upstream.
+func Upstream(repoDir, branch string) (remote, remoteBranch string) {
+	out, err := exec.Command("git", "-C", repoDir, "config", "--get", "branch."+branch+".remote").Output()
+	if err != nil {
+		return "", ""
+	}
+	remote = strings.TrimSpace(string(out))
+	out, err = exec.Command("git", "-C", repoDir, "config", "--get", "branch."+branch+".merge").Output()
+	if err != nil {
+		return "", ""
+	}
+	return remote, strings.TrimPrefix(strings.TrimSpace(string(out)), "refs/heads/")
+}
+
+// PushBranch pushes a local branch to remote and sets it as the upstream.
+func PushBranch(repoDir, remote, branch string) error {
+	out, err := exec.Command("git", "-C", repoDir, "push", "--set-upstream", remote, branch).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// DeleteRemoteBranch deletes a branch on a remote.
+func DeleteRemoteBranch(repoDir, remote, branch string) error {
+	out, err := exec.Command("git", "-C", repoDir, "push", remote, "--delete", branch).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// CreateBranch creates a branch at startPoint without checking it out.
+func CreateBranch(repoDir, name, startPoint string) error {
+	out, err := exec.Command("git", "-C", repoDir, "branch", "--", name, startPoint).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// MergeBase returns the best common ancestor of two commits, or "".
+func MergeBase(repoDir, a, b string) string {
+	out, err := exec.Command("git", "-C", repoDir, "merge-base", a, b).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
@@ -0,0 +1,235 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// MergeMethod is how a branch's work landed in the default branch.
+type MergeMethod string
+
+const (
+	NotMerged    MergeMethod = ""
+	MergedCommit MergeMethod = "merged"        // Branch tip is an ancestor (merge commit or fast-forward)
+	MergedRebase MergeMethod = "rebase-merged" // Every commit has a patch-equivalent commit in the target
+	MergedSquash MergeMethod = "squash-merged" // The branch's combined change is already in the target
+)
+
+// MergeStatus describes whether and how a branch was merged into a target.
+type MergeStatus struct {
+	Target string // What the branch was compared against, e.g. "origin/main"
+	Method MergeMethod
+}
+
+// MergeTarget is the branch merged checks compare against: the default
+// branch, on a remote when there is one.
+type MergeTarget struct {
+	Remote string // "" when the repo has no remote
+	Branch string
+}
+
+// ResolveMergeTarget works out the merge target for a repo. remote and branch
+// are overrides from treework's settings and may be empty; the repo's own
+// treework.remote and treework.defaultBranch git config win over them.
+func ResolveMergeTarget(repoDir, remote, branch string) MergeTarget {
+	if r := configValue(repoDir, "treework.remote"); r != "" || remote == "" {
+		remote = DefaultRemote(repoDir)
+	}
+	if b := configValue(repoDir, "treework.defaultBranch"); b != "" || branch == "" {
+		branch = DefaultBranchFor(repoDir, remote)
+	}
+	return MergeTarget{Remote: remote, Branch: branch}
+}
+
+// String returns the target as git would show it, e.g. "origin/main".
+func (t MergeTarget) String() string {
+	if t.Remote == "" {
+		return t.Branch
+	}
+	return t.Remote + "/" + t.Branch
+}
+
+// Fetch updates the target's remote-tracking branch. It does nothing for a
+// repo without a remote.
+func (t MergeTarget) Fetch(repoDir string) error {
+	if t.Remote == "" {
+		return nil
+	}
+	return FetchBranch(repoDir, t.Remote, t.Branch)
+}
+
+// Merged reports whether the branch's work is in the target by any method.
+func (s MergeStatus) Merged() bool {
+	return s.Method != NotMerged
+}
+
+// Describe returns a short human-readable description, e.g. "squash-merged into main".
+func (s MergeStatus) Describe() string {
+	if !s.Merged() {
+		return "not merged into " + s.Target
+	}
+	return string(s.Method) + " into " + s.Target
+}
+
+// BranchMergeStatus works out whether branch has landed in the repo's
+// detected default branch. See MergeStatusAgainst.
+func BranchMergeStatus(repoDir, branch string) MergeStatus {
+	return MergeStatusAgainst(repoDir, branch, ResolveMergeTarget(repoDir, "", ""))
+}
+
+// MergeStatusAgainst works out whether branch has landed in target,
+// recognising plain merges, rebase merges and squash merges. The remote
+// branch is checked first; the local one as well, since work is sometimes
+// merged locally before it is pushed.
+func MergeStatusAgainst(repoDir, branch string, target MergeTarget) MergeStatus {
+	var checked []string
+	if target.Remote != "" && RevParse(repoDir, "refs/remotes/"+target.String()) != "" {
+		checked = append(checked, "refs/remotes/"+target.String())
+	}
+	if BranchExists(repoDir, target.Branch) {
+		checked = append(checked, "refs/heads/"+target.Branch)
+	}
+
+	for _, ref := range checked {
+		if method := mergeMethod(repoDir, branch, ref); method != NotMerged {
+			name := strings.TrimPrefix(strings.TrimPrefix(ref, "refs/remotes/"), "refs/heads/")
+			return MergeStatus{Target: name, Method: method}
+		}
+	}
+	return MergeStatus{Target: target.String(), Method: NotMerged}
+}
+
+// mergeMethod checks the cheapest signal first.
+func mergeMethod(repoDir, branch, target string) MergeMethod {
+	git := func(args ...string) (string, error) {
+		out, err := exec.Command("git", append([]string{"-C", repoDir}, args...)...).Output()
+		return strings.TrimSpace(string(out)), err
+	}
+	if _, err := git("rev-parse", "--verify", "--quiet", "refs/heads/"+branch); err != nil {
+		return NotMerged
+	}
+
+	// 1. Tip is reachable from the target
+	if _, err := git("merge-base", "--is-ancestor", "refs/heads/"+branch, target); err == nil {
+		return MergedCommit
+	}
+
+	base, err := git("merge-base", target, "refs/heads/"+branch)
+	if err != nil || base == "" {
+		return NotMerged
+	}
+
+	// 2. Rebase merge: git cherry marks commits whose patch-id exists upstream with "-"
+	out, err := git("cherry", target, "refs/heads/"+branch, base)
+	if err == nil && out != "" && !strings.Contains("\n"+out, "\n+") {
+		return MergedRebase
+	}
+
+	// 3. Squash merge: the branch's whole diff, as one patch, has the same
+	// patch-id as a commit on the target. Worked out from diffs rather than
+	// by committing the squash, so checks don't litter the repo with objects
+	diff, err := exec.Command("git", "-C", repoDir, "diff", "--no-color", "--no-ext-diff", base, "refs/heads/"+branch).Output()
+	if squash := patchIDs(repoDir, diff); err == nil && len(squash) == 1 {
+		if targetPatchIDs(repoDir, target)[squash[0]] {
+			return MergedSquash
+		}
+	}
+
+	// 4. Squash merge that was later built on: merging the branch into the
+	// target would change nothing (needs git 2.38+ for merge-tree --write-tree)
+	merged, err := git("merge-tree", "--write-tree", target, "refs/heads/"+branch)
+	if err == nil {
+		tree, _ := git("rev-parse", target+"^{tree}")
+		if tree != "" && strings.SplitN(merged, "\n", 2)[0] == tree {
+			return MergedSquash
+		}
+	}
+
+	return NotMerged
+}
+
+// squashScanDepth is how many of the target's latest commits are searched for
+// a squash merge. Older squash merges are still found by the merge-tree check.
+const squashScanDepth = 1000
+
+var (
+	targetPatchMu sync.Mutex
+	targetPatches = map[string]*targetPatchSet{}
+)
+
+type targetPatchSet struct {
+	once sync.Once
+	ids  map[string]bool
+}
+
+// targetPatchIDs returns the patch-ids of target's latest commits. They're
+// worked out once per repo and target tip and shared by every branch checked
+// against it, since git log -p over a long-lived default branch is slow.
+func targetPatchIDs(repoDir, target string) map[string]bool {
+	key := repoDir + "\x00" + RevParse(repoDir, target)
+	targetPatchMu.Lock()
+	set, ok := targetPatches[key]
+	if !ok {
+		set = &targetPatchSet{}
+		targetPatches[key] = set
+	}
+	targetPatchMu.Unlock()
+
+	set.once.Do(func() {
+		set.ids = map[string]bool{}
+		log, err := exec.Command("git", "-C", repoDir, "log", "-p", "--no-color", "--no-merges",
+			fmt.Sprintf("--max-count=%d", squashScanDepth), target).Output()
+		if err != nil {
+			return
+		}
+		for _, id := range patchIDs(repoDir, log) {
+			set.ids[id] = true
+		}
+	})
+	return set.ids
+}
+
+// patchIDs returns the stable patch-id of each patch in a diff or git log -p
+// output.
+func patchIDs(repoDir string, patches []byte) []string {
+	if len(patches) == 0 {
+		return nil
+	}
+	cmd := exec.Command("git", "-C", repoDir, "patch-id", "--stable")
+	cmd.Stdin = bytes.NewReader(patches)
+	out, err := cmd.Output()
+	if err != nil {
+		return nil
+	}
+	var ids []string
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if id, _, ok := strings.Cut(line, " "); ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// DeleteMergedBranch deletes a branch that status, worked out when the
+// branch was at sha, reported as merged. Squash- and rebase-merged branches
+// aren't ancestors of the target, so git branch -d refuses them; they're
+// force-deleted instead, but only if the branch is still at sha, so commits
+// made since the check aren't lost.
+func DeleteMergedBranch(repoDir, branch, sha string, status MergeStatus) error {
+	if err := DeleteBranch(repoDir, branch); err == nil || !status.Merged() {
+		return err
+	}
+	if sha == "" {
+		return fmt.Errorf("branch '%s' isn't fully merged", branch)
+	}
+	// update-ref only deletes the ref if it still points at sha
+	out, err := exec.Command("git", "-C", repoDir, "update-ref", "-d", "refs/heads/"+branch, sha).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("branch '%s' changed since it was checked: %s", branch, strings.TrimSpace(string(out)))
+	}
+	exec.Command("git", "-C", repoDir, "config", "--remove-section", "branch."+branch).Run()
+	return nil
+}
@@ -0,0 +1,272 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newTestRepo creates a repo on main with one commit, isolated from the
+// user's git config.
+func newTestRepo(t *testing.T) string {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	for _, v := range []string{"GIT_AUTHOR_NAME", "GIT_COMMITTER_NAME"} {
+		t.Setenv(v, "test")
+	}
+	for _, v := range []string{"GIT_AUTHOR_EMAIL", "GIT_COMMITTER_EMAIL"} {
+		t.Setenv(v, "test@example.com")
+	}
+
+	repo := filepath.Join(t.TempDir(), "app")
+	gitRun(t, filepath.Dir(repo), "init", "-q", "-b", "main", repo)
+	commitFile(t, repo, "README", "hello\n", "initial")
+	return repo
+}
+
+func gitRun(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// commitFile writes a file and commits it on the current branch.
+func commitFile(t *testing.T, dir, name, content, message string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	gitRun(t, dir, "add", name)
+	gitRun(t, dir, "commit", "-q", "-m", message)
+}
+
+// featBranch creates branch feat off main with two commits and leaves main
+// checked out with a commit of its own, so feat isn't a fast-forward.
+func featBranch(t *testing.T, repo string) {
+	t.Helper()
+	gitRun(t, repo, "checkout", "-q", "-b", "feat")
+	commitFile(t, repo, "a.txt", "a\n", "add a")
+	commitFile(t, repo, "b.txt", "b\n", "add b")
+	gitRun(t, repo, "checkout", "-q", "main")
+	commitFile(t, repo, "main.txt", "main\n", "work on main")
+}
+
+func TestMergeStatusAgainst(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(t *testing.T, repo string)
+		want  MergeMethod
+	}{
+		{
+			name: "not merged",
+			setup: func(t *testing.T, repo string) {
+				featBranch(t, repo)
+			},
+			want: NotMerged,
+		},
+		{
+			name: "fast-forward",
+			setup: func(t *testing.T, repo string) {
+				gitRun(t, repo, "checkout", "-q", "-b", "feat")
+				commitFile(t, repo, "a.txt", "a\n", "add a")
+				gitRun(t, repo, "checkout", "-q", "main")
+				gitRun(t, repo, "merge", "-q", "--ff-only", "feat")
+			},
+			want: MergedCommit,
+		},
+		{
+			name: "merge commit",
+			setup: func(t *testing.T, repo string) {
+				featBranch(t, repo)
+				gitRun(t, repo, "merge", "-q", "--no-ff", "-m", "merge feat", "feat")
+			},
+			want: MergedCommit,
+		},
+		{
+			name: "rebase merge",
+			setup: func(t *testing.T, repo string) {
+				featBranch(t, repo)
+				gitRun(t, repo, "cherry-pick", "main..feat")
+			},
+			want: MergedRebase,
+		},
+		{
+			name: "rebase merge of some commits",
+			setup: func(t *testing.T, repo string) {
+				featBranch(t, repo)
+				gitRun(t, repo, "cherry-pick", "feat~1")
+			},
+			want: NotMerged,
+		},
+		{
+			name: "squash merge",
+			setup: func(t *testing.T, repo string) {
+				featBranch(t, repo)
+				gitRun(t, repo, "merge", "-q", "--squash", "feat")
+				gitRun(t, repo, "commit", "-q", "-m", "feat (#1)")
+			},
+			want: MergedSquash,
+		},
+		{
+			name: "squash merge built on",
+			setup: func(t *testing.T, repo string) {
+				featBranch(t, repo)
+				gitRun(t, repo, "merge", "-q", "--squash", "feat")
+				gitRun(t, repo, "commit", "-q", "-m", "feat (#1)")
+				commitFile(t, repo, "a.txt", "a, improved\n", "improve a")
+			},
+			want: MergedSquash,
+		},
+		{
+			name: "squash merge with extra changes",
+			setup: func(t *testing.T, repo string) {
+				featBranch(t, repo)
+				gitRun(t, repo, "merge", "-q", "--squash", "feat")
+				if err := os.WriteFile(filepath.Join(repo, "c.txt"), []byte("c\n"), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				gitRun(t, repo, "add", "c.txt")
+				gitRun(t, repo, "commit", "-q", "-m", "feat and c (#1)")
+			},
+			want: MergedSquash,
+		},
+		{
+			name: "squash merge of part of the branch",
+			setup: func(t *testing.T, repo string) {
+				featBranch(t, repo)
+				gitRun(t, repo, "merge", "-q", "--squash", "feat~1")
+				gitRun(t, repo, "commit", "-q", "-m", "half of feat")
+			},
+			want: NotMerged,
+		},
+		{
+			name: "branch missing",
+			setup: func(t *testing.T, repo string) {
+			},
+			want: NotMerged,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newTestRepo(t)
+			tt.setup(t, repo)
+			got := MergeStatusAgainst(repo, "feat", MergeTarget{Branch: "main"})
+			if got.Method != tt.want {
+				t.Errorf("Method = %q, want %q", got.Method, tt.want)
+			}
+			if got.Target != "main" {
+				t.Errorf("Target = %q, want main", got.Target)
+			}
+		})
+	}
+}
+
+func TestMergeStatusAgainstRemote(t *testing.T) {
+	upstream := newTestRepo(t)
+	featBranch(t, upstream)
+
+	clone := filepath.Join(t.TempDir(), "clone")
+	gitRun(t, upstream, "clone", "-q", upstream, clone)
+	gitRun(t, clone, "branch", "-q", "feat", "origin/feat")
+
+	// Merged on the remote only, so only the remote-tracking branch has it
+	gitRun(t, upstream, "merge", "-q", "--squash", "feat")
+	gitRun(t, upstream, "commit", "-q", "-m", "feat (#1)")
+
+	target := MergeTarget{Remote: "origin", Branch: "main"}
+	if got := MergeStatusAgainst(clone, "feat", target); got.Merged() {
+		t.Fatalf("before fetching: %s, want not merged", got.Describe())
+	}
+	if err := target.Fetch(clone); err != nil {
+		t.Fatal(err)
+	}
+	got := MergeStatusAgainst(clone, "feat", target)
+	if got.Method != MergedSquash || got.Target != "origin/main" {
+		t.Errorf("after fetching: %+v, want squash-merged into origin/main", got)
+	}
+}
+
+func TestMergeStatusDescribe(t *testing.T) {
+	tests := []struct {
+		status     MergeStatus
+		wantMerged bool
+		want       string
+	}{
+		{MergeStatus{Target: "main"}, false, "not merged into main"},
+		{MergeStatus{Target: "origin/main", Method: MergedCommit}, true, "merged into origin/main"},
+		{MergeStatus{Target: "main", Method: MergedRebase}, true, "rebase-merged into main"},
+		{MergeStatus{Target: "upstream/develop", Method: MergedSquash}, true, "squash-merged into upstream/develop"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := tt.status.Merged(); got != tt.wantMerged {
+				t.Errorf("Merged = %v, want %v", got, tt.wantMerged)
+			}
+			if got := tt.status.Describe(); got != tt.want {
+				t.Errorf("Describe = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeleteMergedBranch(t *testing.T) {
+	tests := []struct {
+		name      string
+		squash    bool // Squash-merge feat rather than merging it
+		moveAfter bool // Commit to feat after the check
+		status    MergeMethod
+		wantKept  bool
+		wantErr   string // Part of the error, if the branch is kept
+	}{
+		{name: "merged", status: MergedCommit},
+		{name: "squash-merged", squash: true, status: MergedSquash},
+		{name: "squash-merged, moved since", squash: true, moveAfter: true, status: MergedSquash, wantKept: true, wantErr: "changed since it was checked"},
+		{name: "not merged", squash: true, status: NotMerged, wantKept: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newTestRepo(t)
+			featBranch(t, repo)
+			if tt.squash {
+				gitRun(t, repo, "merge", "-q", "--squash", "feat")
+				gitRun(t, repo, "commit", "-q", "-m", "feat (#1)")
+			} else {
+				gitRun(t, repo, "merge", "-q", "--no-ff", "-m", "merge feat", "feat")
+			}
+			gitRun(t, repo, "config", "branch.feat.description", "kept with the branch")
+
+			checked := RevParse(repo, "refs/heads/feat")
+			if tt.moveAfter {
+				gitRun(t, repo, "checkout", "-q", "feat")
+				commitFile(t, repo, "late.txt", "late\n", "late work")
+				gitRun(t, repo, "checkout", "-q", "main")
+			}
+
+			err := DeleteMergedBranch(repo, "feat", checked, MergeStatus{Target: "main", Method: tt.status})
+			if tt.wantKept {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want it to contain %q", err, tt.wantErr)
+				}
+				if !BranchExists(repo, "feat") {
+					t.Error("branch was deleted")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if BranchExists(repo, "feat") {
+				t.Error("branch still exists")
+			}
+			if out, _ := exec.Command("git", "-C", repo, "config", "--get-regexp", `^branch\.feat\.`).Output(); len(out) > 0 {
+				t.Errorf("branch config left behind: %s", out)
+			}
+		})
+	}
+}
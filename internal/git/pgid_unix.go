@@ -0,0 +1,16 @@
+//go:build !windows
+
+package git
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// uninterruptible starts cmd in its own process group, so the SIGINT a
+// terminal sends on Ctrl+C doesn't stop it halfway. treework cancels between
+// commands instead.
+func uninterruptible(cmd *exec.Cmd) *exec.Cmd {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	return cmd
+}
@@ -0,0 +1,9 @@
+package git
+
+import "os/exec"
+
+// uninterruptible leaves cmd as it is on Windows, which has no Unix
+// process groups.
+func uninterruptible(cmd *exec.Cmd) *exec.Cmd {
+	return cmd
+}
@@ -0,0 +1,204 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Ref is a git ref with the commit it points to.
+type Ref struct {
+	Name    string
+	SHA     string
+	Created time.Time // Committer date of the commit
+}
+
+// Snapshot records everything in a worktree — staged, unstaged and untracked
+// files, but not ignored ones — as a commit on top of HEAD, without touching
+// the worktree, its index or any branch. Returns the new commit's SHA.
+func Snapshot(wtPath, message string) (string, error) {
+	return snapshot([]string{"-C", wtPath}, RevParse(wtPath, "HEAD"), message)
+}
+
+// SnapshotDir records a folder git has lost track of — every file in it
+// but .git and ignored ones — as a commit in repoDir on top of base, so it
+// can be backed up like a worktree. Returns the new commit's SHA.
+func SnapshotDir(repoDir, dir, base, message string) (string, error) {
+	out, err := exec.Command("git", "-C", repoDir, "rev-parse", "--absolute-git-dir").Output()
+	if err != nil {
+		return "", fmt.Errorf("%s is not a git repo", repoDir)
+	}
+	gitDir := strings.TrimSpace(string(out))
+	return snapshot([]string{"-C", dir, "--git-dir", gitDir, "--work-tree", dir}, base, message)
+}
+
+// snapshot commits the files git sees with the given global options onto
+// head, using a throwaway index.
+func snapshot(gitArgs []string, head, message string) (string, error) {
+	index, err := os.CreateTemp("", "treework-index-")
+	if err != nil {
+		return "", err
+	}
+	index.Close()
+	os.Remove(index.Name()) // git wants to create the index itself
+	defer os.Remove(index.Name())
+
+	env := append(os.Environ(), "GIT_INDEX_FILE="+index.Name())
+	if exec.Command("git", append(gitArgs, "var", "GIT_COMMITTER_IDENT")...).Run() != nil {
+		// No user.name/user.email configured; commit-tree would refuse
+		env = append(env,
+			"GIT_AUTHOR_NAME=treework", "GIT_AUTHOR_EMAIL=treework@localhost",
+			"GIT_COMMITTER_NAME=treework", "GIT_COMMITTER_EMAIL=treework@localhost")
+	}
+	run := func(args ...string) (string, error) {
+		cmd := exec.Command("git", append(slices.Clone(gitArgs), args...)...)
+		cmd.Env = env
+		out, err := cmd.Output()
+		if err != nil {
+			if exitErr, ok := err.(*exec.ExitError); ok {
+				return "", fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(string(exitErr.Stderr)))
+			}
+			return "", err
+		}
+		return strings.TrimSpace(string(out)), nil
+	}
+
+	// Seed the temporary index from HEAD so only changed files are hashed
+	if head != "" {
+		if _, err := run("read-tree", head); err != nil {
+			return "", err
+		}
+	}
+	if _, err := run("add", "--all", "."); err != nil {
+		return "", err
+	}
+	tree, err := run("write-tree")
+	if err != nil {
+		return "", err
+	}
+	args := []string{"commit-tree", tree, "-m", message}
+	if head != "" {
+		args = append(args, "-p", head)
+	}
+	return run(args...)
+}
+
+// RevParse resolves a revision to a commit SHA, or "" if it doesn't exist.
+func RevParse(dir, rev string) string {
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "--verify", "--quiet", rev+"^{commit}").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// SameTree reports whether commits a and b hold exactly the same files.
+// Snapshots are always new commits, so comparing SHAs can't tell whether
+// one captured any changes.
+func SameTree(dir, a, b string) bool {
+	out, err := exec.Command("git", "-C", dir, "rev-parse", a+"^{tree}", b+"^{tree}").Output()
+	if err != nil {
+		return false
+	}
+	trees := strings.Fields(string(out))
+	return len(trees) == 2 && trees[0] == trees[1]
+}
+
+// UpdateRef points ref at sha, creating it if needed.
+func UpdateRef(repoDir, ref, sha string) error {
+	out, err := exec.Command("git", "-C", repoDir, "update-ref", ref, sha).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// CreateRef creates ref pointing at sha. Unlike UpdateRef it fails if ref
+// already exists rather than overwriting it.
+func CreateRef(repoDir, ref, sha string) error {
+	out, err := exec.Command("git", "-C", repoDir, "update-ref", ref, sha, "").CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// DeleteRef deletes a ref.
+func DeleteRef(repoDir, ref string) error {
+	out, err := exec.Command("git", "-C", repoDir, "update-ref", "-d", ref).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// ListRefs returns the refs under prefix (e.g. "refs/treework/"), sorted by name.
+func ListRefs(repoDir, prefix string) []Ref {
+	out, err := exec.Command("git", "-C", repoDir, "for-each-ref",
+		"--format=%(refname) %(objectname) %(committerdate:unix)", prefix).Output()
+	if err != nil {
+		return nil
+	}
+	var refs []Ref
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			continue
+		}
+		secs, _ := strconv.ParseInt(fields[2], 10, 64)
+		refs = append(refs, Ref{Name: fields[0], SHA: fields[1], Created: time.Unix(secs, 0)})
+	}
+	return refs
+}
+
+// CommitMessage returns the full message of a commit.
+func CommitMessage(dir, sha string) string {
+	out, err := exec.Command("git", "-C", dir, "log", "-1", "--format=%B", sha).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// DiffStat returns a diffstat summary between two commits.
+func DiffStat(dir, from, to string) string {
+	out, err := exec.Command("git", "-C", dir, "diff", "--stat", from, to).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimRight(string(out), "\n")
+}
+
+// RestoreWorktree overwrites the files in a worktree with those from a
+// commit, leaving the index and HEAD alone so the changes show as unstaged.
+func RestoreWorktree(wtPath, source string) error {
+	out, err := exec.Command("git", "-C", wtPath, "restore", "--source="+source, "--worktree", "--", ".").CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// ApplyChanges applies the difference between two commits to a worktree's
+// files, so work snapshotted on an older commit can be replayed on a newer one.
+func ApplyChanges(wtPath, from, to string) error {
+	diff, err := exec.Command("git", "-C", wtPath, "diff", "--binary", from, to).Output()
+	if err != nil {
+		return err
+	}
+	if len(diff) == 0 {
+		return nil
+	}
+	cmd := exec.Command("git", "-C", wtPath, "apply", "--whitespace=nowarn")
+	cmd.Stdin = bytes.NewReader(diff)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
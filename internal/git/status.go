@@ -0,0 +1,282 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WorktreeStatus describes the state of a worktree's working directory and
+// the work in it that removing the worktree could lose.
+type WorktreeStatus struct {
+	HasUncommittedChanges bool // Modified, staged, or untracked files
+	HasUnpushedCommits    bool // Commits in Unpushed
+
+	Staged     int
+	Modified   int
+	Untracked  int
+	Conflicted int
+	Files      []string // One per changed path, e.g. "M  src/app.go" (index and worktree state, then path)
+
+	Branch   string   // "" on a detached HEAD
+	Upstream string   // Tracked remote branch, e.g. "origin/feature", or ""
+	Ahead    int      // Commits not on Upstream
+	Behind   int      // Upstream commits not in the branch
+	Unpushed []string // Commits at risk, "sha subject": not on Upstream, on any remote if there's no upstream, or on any branch or tag when detached
+
+	Operation       string   // "merge", "rebase", "cherry-pick", "revert" or "bisect" in progress, or ""
+	DirtySubmodules []string // Submodules with changes of their own
+	Stashes         []string // Stash entries made on Branch; kept by git, but easy to forget
+}
+
+// CheckWorktreeStatus inspects a worktree for unsaved work.
+// If git commands fail, assumes the worktree is dirty (fail safe).
+func CheckWorktreeStatus(wtPath string) WorktreeStatus {
+	var s WorktreeStatus
+
+	out, err := exec.Command("git", "-C", wtPath, "status", "--porcelain=v2", "--branch").Output()
+	if err != nil {
+		// Git failed — assume dirty to prevent accidental deletion
+		s.HasUncommittedChanges = true
+		return s
+	}
+	s.parsePorcelain(string(out))
+	s.HasUncommittedChanges = len(s.Files) > 0
+
+	// Commits at risk
+	var logArgs []string
+	switch {
+	case s.Branch == "":
+		logArgs = []string{"HEAD", "--not", "--branches", "--tags", "--remotes"}
+	case s.Upstream != "":
+		logArgs = []string{s.Branch, "--not", s.Upstream}
+	default:
+		logArgs = []string{s.Branch, "--not", "--remotes"}
+	}
+	out, err = exec.Command("git", append([]string{"-C", wtPath, "log", "--format=%h %s"}, logArgs...)...).Output()
+	if err != nil {
+		// Git failed — assume dirty to prevent accidental deletion
+		s.HasUnpushedCommits = true
+	} else if lines := strings.TrimSpace(string(out)); lines != "" {
+		s.Unpushed = strings.Split(lines, "\n")
+		s.HasUnpushedCommits = true
+	}
+
+	s.Operation = operationInProgress(wtPath)
+	if s.Branch != "" {
+		s.Stashes = branchStashes(wtPath, s.Branch)
+	}
+	return s
+}
+
+// parsePorcelain reads the output of git status --porcelain=v2 --branch.
+func (s *WorktreeStatus) parsePorcelain(out string) {
+	tracking := false // branch.ab is missing when the upstream branch is gone
+	defer func() {
+		if !tracking {
+			s.Upstream = ""
+		}
+	}()
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		switch fields[0] {
+		case "#":
+			switch fields[1] {
+			case "branch.head":
+				if len(fields) > 2 && fields[2] != "(detached)" {
+					s.Branch = fields[2]
+				}
+			case "branch.upstream":
+				if len(fields) > 2 {
+					s.Upstream = fields[2]
+				}
+			case "branch.ab":
+				if len(fields) > 3 {
+					tracking = true
+					s.Ahead, _ = strconv.Atoi(strings.TrimPrefix(fields[2], "+"))
+					s.Behind, _ = strconv.Atoi(strings.TrimPrefix(fields[3], "-"))
+				}
+			}
+		case "?":
+			s.Untracked++
+			s.Files = append(s.Files, "?? "+porcelainPath(line, 1))
+		case "u":
+			s.Conflicted++
+			s.Files = append(s.Files, fields[1]+" "+porcelainPath(line, 10))
+		case "1", "2":
+			if len(fields) < 3 {
+				continue
+			}
+			xy, sub := fields[1], fields[2]
+			if xy[0] != '.' {
+				s.Staged++
+			}
+			if xy[1] != '.' {
+				s.Modified++
+			}
+			skip := 8 // "1 XY sub mH mI mW hH hI path"
+			if fields[0] == "2" {
+				skip = 9 // Renames add a score before the path
+			}
+			path := porcelainPath(line, skip)
+			if fields[0] == "2" {
+				path, _, _ = strings.Cut(path, "\t")
+			}
+			s.Files = append(s.Files, strings.ReplaceAll(xy, ".", " ")+" "+path)
+			// Submodule field is S<commit changed><modified><untracked>
+			if strings.HasPrefix(sub, "S") && len(sub) == 4 && (sub[2] == 'M' || sub[3] == 'U') {
+				s.DirtySubmodules = append(s.DirtySubmodules, path)
+			}
+		}
+	}
+}
+
+// porcelainPath returns what follows the first n space-separated fields of a
+// porcelain line. Paths may contain spaces, so they can't be split on.
+func porcelainPath(line string, n int) string {
+	rest := line
+	for i := 0; i < n; i++ {
+		_, after, ok := strings.Cut(rest, " ")
+		if !ok {
+			return ""
+		}
+		rest = after
+	}
+	return rest
+}
+
+// operationInProgress reports a merge, rebase, cherry-pick, revert or
+// bisect that was started in the worktree and not finished.
+func operationInProgress(wtPath string) string {
+	out, err := exec.Command("git", "-C", wtPath, "rev-parse", "--absolute-git-dir").Output()
+	if err != nil {
+		return ""
+	}
+	gitDir := strings.TrimSpace(string(out))
+	checks := []struct{ file, op string }{
+		{"rebase-merge", "rebase"},
+		{"rebase-apply", "rebase"},
+		{"MERGE_HEAD", "merge"},
+		{"CHERRY_PICK_HEAD", "cherry-pick"},
+		{"REVERT_HEAD", "revert"},
+		{"BISECT_LOG", "bisect"},
+	}
+	for _, c := range checks {
+		if _, err := os.Stat(filepath.Join(gitDir, c.file)); err == nil {
+			return c.op
+		}
+	}
+	return ""
+}
+
+// branchStashes lists stash entries made while branch was checked out.
+func branchStashes(wtPath, branch string) []string {
+	out, err := exec.Command("git", "-C", wtPath, "stash", "list", "--format=%gd %gs").Output()
+	if err != nil {
+		return nil
+	}
+	var stashes []string
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		// Subjects look like "WIP on feature: abc1234 msg" or "On feature: msg"
+		if strings.Contains(line, " on "+branch+": ") || strings.Contains(line, " On "+branch+": ") {
+			stashes = append(stashes, line)
+		}
+	}
+	return stashes
+}
+
+// IsDirty returns true if the worktree has any unsaved work that would be lost.
+func (s WorktreeStatus) IsDirty() bool {
+	return s.HasUncommittedChanges || s.HasUnpushedCommits || s.Operation != ""
+}
+
+// ChangeSummary counts the uncommitted changes, e.g. "2 modified, 1 untracked",
+// or returns "" if there are none.
+func (s WorktreeStatus) ChangeSummary() string {
+	if !s.HasUncommittedChanges {
+		return ""
+	}
+	var parts []string
+	add := func(n int, what string) {
+		if n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", n, what))
+		}
+	}
+	add(s.Staged, "staged")
+	add(s.Modified, "modified")
+	add(s.Untracked, "untracked")
+	add(s.Conflicted, "conflicted")
+	if len(parts) == 0 {
+		return "uncommitted changes"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Summary describes all the unsaved work in a few words, e.g.
+// "2 modified, 1 untracked, 3 unpushed commit(s), rebase in progress".
+func (s WorktreeStatus) Summary() string {
+	var parts []string
+	add := func(n int, what string) {
+		if n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", n, what))
+		}
+	}
+	if c := s.ChangeSummary(); c != "" {
+		parts = append(parts, c)
+	}
+	switch {
+	case len(s.Unpushed) > 0 && s.Branch == "":
+		add(len(s.Unpushed), "commit(s) on no branch")
+	case len(s.Unpushed) > 0:
+		add(len(s.Unpushed), "unpushed commit(s)")
+	case s.HasUnpushedCommits:
+		parts = append(parts, "unpushed commits")
+	}
+	add(len(s.DirtySubmodules), "dirty submodule(s)")
+	if s.Operation != "" {
+		parts = append(parts, s.Operation+" in progress")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// LastActivity returns when the worktree was last worked in: the newest of
+// its HEAD commit and the uncommitted files in s.
+func LastActivity(wtPath string, s WorktreeStatus) time.Time {
+	var last time.Time
+	out, err := exec.Command("git", "-C", wtPath, "log", "-1", "--format=%ct").Output()
+	if err == nil {
+		if sec, err := strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64); err == nil {
+			last = time.Unix(sec, 0)
+		}
+	}
+	for _, f := range s.Files {
+		if len(f) < 4 {
+			continue
+		}
+		// Entries are "XY path"; deleted files have no time to offer
+		if info, err := os.Stat(filepath.Join(wtPath, f[3:])); err == nil && info.ModTime().After(last) {
+			last = info.ModTime()
+		}
+	}
+	return last
+}
+
+// RecentCommits returns the worktree's last n commits, newest first, as
+// "sha subject (2 days ago)".
+func RecentCommits(wtPath string, n int) []string {
+	out, err := exec.Command("git", "-C", wtPath, "log", "-n", strconv.Itoa(n), "--format=%h %s (%cr)").Output()
+	if err != nil {
+		return nil
+	}
+	if lines := strings.TrimSpace(string(out)); lines != "" {
+		return strings.Split(lines, "\n")
+	}
+	return nil
+}
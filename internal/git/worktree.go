@@ -12,8 +12,13 @@ import (
 
 // WorktreeInfo holds parsed worktree data.
 type WorktreeInfo struct {
-	Path   string
-	Branch string
+	Path       string
+	Branch     string
+	Head       string // Commit SHA checked out
+	Detached   bool
+	Prunable   string // Why git considers the entry stale (e.g. folder deleted), or ""
+	Locked     bool
+	LockReason string
 }
 
 // WorktreeAdd creates a new worktree. If newBranch is true, creates a new branch.
@@ -29,57 +34,82 @@ func WorktreeAdd(repoDir, wtPath, branchName string, newBranch bool) error {
 	return cmd.Run()
 }
 
-// WorktreeStatus describes the state of a worktree's working directory.
-type WorktreeStatus struct {
-	HasUncommittedChanges bool // Modified, staged, or untracked files
-	HasUnpushedCommits    bool // Commits not pushed to any remote
+// WorktreeAddTracking creates a worktree on a new branch that starts at, and
+// tracks, the branch of the same name on remote.
+func WorktreeAddTracking(repoDir, wtPath, branchName, remote string) error {
+	cmd := exec.Command("git", "-C", repoDir, "worktree", "add", "--track", "-b", branchName, wtPath, remote+"/"+branchName)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
 }
 
-// CheckWorktreeStatus inspects a worktree for unsaved work.
-// If git commands fail, assumes the worktree is dirty (fail safe).
-func CheckWorktreeStatus(wtPath string) WorktreeStatus {
-	var s WorktreeStatus
+// WorktreeRemove removes a clean worktree. Returns an error if the worktree
+// has uncommitted changes (does NOT force).
+func WorktreeRemove(repoDir, wtPath string) error {
+	return runRemove(exec.Command("git", "-C", repoDir, "worktree", "remove", wtPath))
+}
 
-	// Check for modified, staged, or untracked files
-	out, err := exec.Command("git", "-C", wtPath, "status", "--porcelain").Output()
-	if err != nil {
-		// Git failed — assume dirty to prevent accidental deletion
-		s.HasUncommittedChanges = true
-		return s
-	}
-	if len(strings.TrimSpace(string(out))) > 0 {
-		s.HasUncommittedChanges = true
-	}
+// WorktreeForceRemove removes a worktree even if it has uncommitted changes.
+func WorktreeForceRemove(repoDir, wtPath string) error {
+	return runRemove(exec.Command("git", "-C", repoDir, "worktree", "remove", "--force", wtPath))
+}
 
-	// Check for commits not on any remote
-	branch := CurrentBranch(wtPath)
-	if branch != "" && branch != "HEAD" {
-		out, err = exec.Command("git", "-C", wtPath, "log", branch, "--not", "--remotes", "--oneline").Output()
-		if err != nil {
-			// Git failed — assume dirty to prevent accidental deletion
-			s.HasUnpushedCommits = true
-		} else if len(strings.TrimSpace(string(out))) > 0 {
-			s.HasUnpushedCommits = true
+// runRemove runs a git worktree remove so Ctrl+C can't interrupt it, and
+// returns git's message if it fails.
+func runRemove(cmd *exec.Cmd) error {
+	out, err := uninterruptible(cmd).CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("%s", strings.TrimPrefix(msg, "fatal: "))
 		}
+		return err
 	}
+	return nil
+}
 
-	return s
+// WorktreeLock locks a worktree so git (and treework) won't remove, move or
+// prune it. The reason is optional.
+func WorktreeLock(repoDir, wtPath, reason string) error {
+	args := []string{"-C", repoDir, "worktree", "lock"}
+	if reason != "" {
+		args = append(args, "--reason", reason)
+	}
+	out, err := exec.Command("git", append(args, wtPath)...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s", strings.TrimSpace(string(out)))
+	}
+	return nil
 }
 
-// IsDirty returns true if the worktree has any unsaved work that would be lost.
-func (s WorktreeStatus) IsDirty() bool {
-	return s.HasUncommittedChanges || s.HasUnpushedCommits
+// WorktreeUnlock unlocks a worktree.
+func WorktreeUnlock(repoDir, wtPath string) error {
+	out, err := exec.Command("git", "-C", repoDir, "worktree", "unlock", wtPath).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s", strings.TrimSpace(string(out)))
+	}
+	return nil
 }
 
-// WorktreeRemove removes a clean worktree. Returns an error if the worktree
-// has uncommitted changes (does NOT force).
-func WorktreeRemove(repoDir, wtPath string) error {
-	return exec.Command("git", "-C", repoDir, "worktree", "remove", wtPath).Run()
+// LockStatus reports whether a linked worktree is locked and why.
+func LockStatus(wtPath string) (locked bool, reason string) {
+	gitDir := LinkedGitDir(wtPath)
+	if gitDir == "" {
+		return false, ""
+	}
+	data, err := os.ReadFile(filepath.Join(gitDir, "locked"))
+	if err != nil {
+		return false, ""
+	}
+	return true, strings.TrimSpace(string(data))
 }
 
-// WorktreeForceRemove removes a worktree even if it has uncommitted changes.
-func WorktreeForceRemove(repoDir, wtPath string) error {
-	return exec.Command("git", "-C", repoDir, "worktree", "remove", "--force", wtPath).Run()
+// WorktreeMove moves a worktree folder and updates git's records of it.
+func WorktreeMove(repoDir, from, to string) error {
+	out, err := exec.Command("git", "-C", repoDir, "worktree", "move", from, to).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s", strings.TrimSpace(string(out)))
+	}
+	return nil
 }
 
 // WorktreePrune prunes stale worktree references.
@@ -93,20 +123,25 @@ func WorktreeList(repoDir string) []WorktreeInfo {
 	if err != nil {
 		return nil
 	}
+	return parseWorktreeList(string(out))
+}
 
+// parseWorktreeList reads git worktree list --porcelain output.
+func parseWorktreeList(out string) []WorktreeInfo {
 	var worktrees []WorktreeInfo
 	var current WorktreeInfo
-	scanner := bufio.NewScanner(strings.NewReader(string(out)))
-	first := true
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	entries := 0
 
 	for scanner.Scan() {
 		line := scanner.Text()
 
 		if strings.HasPrefix(line, "worktree ") {
-			if !first && current.Path != "" {
+			// The first entry is always the main worktree — skip it
+			if entries > 1 && current.Path != "" {
 				worktrees = append(worktrees, current)
 			}
-			first = false
+			entries++
 			current = WorktreeInfo{
 				Path: strings.TrimPrefix(line, "worktree "),
 			}
@@ -114,19 +149,73 @@ func WorktreeList(repoDir string) []WorktreeInfo {
 			branch := strings.TrimPrefix(line, "branch ")
 			branch = strings.TrimPrefix(branch, "refs/heads/")
 			current.Branch = branch
+		} else if strings.HasPrefix(line, "HEAD ") {
+			current.Head = strings.TrimPrefix(line, "HEAD ")
+		} else if line == "detached" {
+			current.Detached = true
+		} else if line == "locked" || strings.HasPrefix(line, "locked ") {
+			current.Locked = true
+			current.LockReason = strings.TrimSpace(strings.TrimPrefix(line, "locked"))
+		} else if line == "prunable" || strings.HasPrefix(line, "prunable ") {
+			current.Prunable = strings.TrimSpace(strings.TrimPrefix(line, "prunable"))
+			if current.Prunable == "" {
+				current.Prunable = "stale"
+			}
 		}
 	}
 
-	// Don't forget the last entry, but skip first (main worktree)
-	if current.Path != "" && len(worktrees) > 0 {
+	// Don't forget the last entry (unless it was the main worktree)
+	if entries > 1 && current.Path != "" {
 		worktrees = append(worktrees, current)
-	} else if current.Path != "" && !first {
-		// This was the only entry (the main worktree) — skip it
 	}
 
 	return worktrees
 }
 
+// WorktreeRepair fixes the links between a repo and its worktrees after
+// either was moved by hand. With paths, repairs those worktrees specifically.
+func WorktreeRepair(repoDir string, paths ...string) error {
+	args := append([]string{"-C", repoDir, "worktree", "repair"}, paths...)
+	return exec.Command("git", args...).Run()
+}
+
+// WorktreeReregister re-attaches an existing folder to repoDir as a worktree
+// after git has forgotten it, checking out branch (or detaching at it) without
+// touching the folder's files. Differences from the branch show up as changes.
+// If branch is already checked out elsewhere, the folder is detached at its tip.
+func WorktreeReregister(repoDir, wtPath, branch string) error {
+	tmp, err := os.MkdirTemp(filepath.Dir(wtPath), ".treework-reregister-")
+	if err != nil {
+		return err
+	}
+	os.Remove(tmp) // git worktree add wants to create it
+	defer os.RemoveAll(tmp)
+
+	// Check out the branch if it's free, otherwise detach at its tip
+	out, err := exec.Command("git", "-C", repoDir, "worktree", "add", "--no-checkout", tmp, branch).CombinedOutput()
+	if err != nil {
+		out, err = exec.Command("git", "-C", repoDir, "worktree", "add", "--no-checkout", "--detach", tmp, branch).CombinedOutput()
+	}
+	if err != nil {
+		return fmt.Errorf("%s", strings.TrimSpace(string(out)))
+	}
+
+	// Move the new .git link into the orphan folder and point git at it
+	data, err := os.ReadFile(filepath.Join(tmp, ".git"))
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(wtPath, ".git"), data, 0o644); err != nil {
+		return err
+	}
+	if err := WorktreeRepair(repoDir, wtPath); err != nil {
+		return err
+	}
+
+	// Fill the index from HEAD so untouched files don't show as deleted
+	return exec.Command("git", "-C", wtPath, "reset", "--quiet", "--mixed").Run()
+}
+
 // MainWorktreePath returns the path of the main worktree for a repo.
 func MainWorktreePath(wtPath string) string {
 	out, err := exec.Command("git", "-C", wtPath, "worktree", "list", "--porcelain").Output()
@@ -152,13 +241,55 @@ func CurrentBranch(wtPath string) string {
 	return strings.TrimSpace(string(out))
 }
 
-// WorktreePath computes the worktree path: ../{repo}-worktree-{name}
-func WorktreePath(repoDir, name string) string {
+// WorktreePath computes the worktree path from a layout template such as
+// "{repo}-worktree-{name}", relative to the repo's parent folder.
+func WorktreePath(repoDir, name, layout string) string {
 	repo := filepath.Base(repoDir)
-	return filepath.Join(filepath.Dir(repoDir), fmt.Sprintf("%s-worktree-%s", repo, name))
+	rel := strings.NewReplacer("{repo}", repo, "{name}", name).Replace(layout)
+	return filepath.Join(filepath.Dir(repoDir), rel)
+}
+
+// LinkedGitDir returns the git directory a worktree's .git file points to,
+// or empty string if dir has no .git file (main repos have a .git directory).
+func LinkedGitDir(dir string) string {
+	data, err := os.ReadFile(filepath.Join(dir, ".git"))
+	if err != nil {
+		return ""
+	}
+	gitDir, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir: ")
+	if !ok {
+		return ""
+	}
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(dir, gitDir)
+	}
+	return filepath.Clean(gitDir)
+}
+
+// IsLinkedWorktree reports whether dir is a linked worktree, judged by its
+// .git file pointing into a repo's worktrees folder.
+func IsLinkedWorktree(dir string) bool {
+	gitDir := LinkedGitDir(dir)
+	return gitDir != "" && filepath.Base(filepath.Dir(gitDir)) == "worktrees"
+}
+
+// MainRepoDir returns the main repo of a linked worktree by reading its .git
+// file, without running git. Falls back to asking git for moved layouts.
+func MainRepoDir(wtPath string) string {
+	if gitDir := LinkedGitDir(wtPath); gitDir != "" && filepath.Base(filepath.Dir(gitDir)) == "worktrees" {
+		common := filepath.Dir(filepath.Dir(gitDir))
+		if filepath.Base(common) == ".git" {
+			return filepath.Dir(common)
+		}
+		return common // bare repo
+	}
+	return MainWorktreePath(wtPath)
 }
 
-// FindWorktreeDirs scans devDir for directories matching *-worktree-* pattern.
+// FindWorktreeDirs scans devDir for worktree folders: linked worktrees,
+// recognised by their .git file, plus folders matching the *-worktree-*
+// pattern that aren't repos of their own (so orphaned worktree folders are
+// still found).
 func FindWorktreeDirs(devDir string) []string {
 	var dirs []string
 	maxDepth := strings.Count(filepath.Clean(devDir), string(os.PathSeparator)) + 3
@@ -173,7 +304,7 @@ func FindWorktreeDirs(devDir string) []string {
 			return fs.SkipDir
 		}
 
-		if d.IsDir() && strings.Contains(d.Name(), "-worktree-") {
+		if d.IsDir() && strings.Contains(d.Name(), "-worktree-") && !isRepoDir(path) {
 			// Skip .git subdirectories
 			if !strings.Contains(path, "/.git/") {
 				dirs = append(dirs, path)
@@ -181,8 +312,32 @@ func FindWorktreeDirs(devDir string) []string {
 			return fs.SkipDir
 		}
 
+		if d.Name() == ".git" {
+			if d.IsDir() {
+				return fs.SkipDir // git internals
+			}
+			if dir := filepath.Dir(path); dir != devDir && IsLinkedWorktree(dir) {
+				dirs = append(dirs, dir)
+				return fs.SkipDir // skips the rest of this worktree
+			}
+		}
+
+		if d.IsDir() && d.Name() == "node_modules" {
+			return fs.SkipDir
+		}
+
 		return nil
 	})
 
 	return dirs
 }
+
+// isRepoDir reports whether dir is a main worktree: it has a .git folder, or
+// a .git file that doesn't point into a repo's worktrees folder.
+func isRepoDir(dir string) bool {
+	info, err := os.Stat(filepath.Join(dir, ".git"))
+	if err != nil {
+		return false
+	}
+	return info.IsDir() || (LinkedGitDir(dir) != "" && !IsLinkedWorktree(dir))
+}
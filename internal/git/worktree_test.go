@@ -0,0 +1,61 @@
+package git
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseWorktreeList(t *testing.T) {
+	const main = "worktree /src/app\nHEAD 1111111111111111111111111111111111111111\nbranch refs/heads/main\n\n"
+	tests := []struct {
+		name string
+		out  string
+		want []WorktreeInfo
+	}{
+		{name: "empty", out: ""},
+		{name: "main only", out: main},
+		{
+			name: "branch",
+			out:  main + "worktree /src/app-worktree-feat\nHEAD 2222222222222222222222222222222222222222\nbranch refs/heads/feat/login\n\n",
+			want: []WorktreeInfo{{Path: "/src/app-worktree-feat", Head: "2222222222222222222222222222222222222222", Branch: "feat/login"}},
+		},
+		{
+			name: "detached",
+			out:  main + "worktree /src/app-worktree-x\nHEAD 3333333333333333333333333333333333333333\ndetached\n\n",
+			want: []WorktreeInfo{{Path: "/src/app-worktree-x", Head: "3333333333333333333333333333333333333333", Detached: true}},
+		},
+		{
+			name: "locked with reason",
+			out:  main + "worktree /mnt/usb/app-worktree-y\nHEAD 4444444444444444444444444444444444444444\nbranch refs/heads/y\nlocked on the usb drive\n\n",
+			want: []WorktreeInfo{{Path: "/mnt/usb/app-worktree-y", Head: "4444444444444444444444444444444444444444", Branch: "y", Locked: true, LockReason: "on the usb drive"}},
+		},
+		{
+			name: "locked without reason",
+			out:  main + "worktree /src/app-worktree-z\nHEAD 5555555555555555555555555555555555555555\nbranch refs/heads/z\nlocked\n\n",
+			want: []WorktreeInfo{{Path: "/src/app-worktree-z", Head: "5555555555555555555555555555555555555555", Branch: "z", Locked: true}},
+		},
+		{
+			name: "prunable",
+			out: main +
+				"worktree /src/gone\nHEAD 6666666666666666666666666666666666666666\nbranch refs/heads/gone\nprunable gitdir file points to non-existent location\n\n" +
+				"worktree /src/stale\nHEAD 7777777777777777777777777777777777777777\nbranch refs/heads/stale\nprunable\n",
+			want: []WorktreeInfo{
+				{Path: "/src/gone", Head: "6666666666666666666666666666666666666666", Branch: "gone", Prunable: "gitdir file points to non-existent location"},
+				{Path: "/src/stale", Head: "7777777777777777777777777777777777777777", Branch: "stale", Prunable: "stale"},
+			},
+		},
+		{
+			name: "no trailing blank line",
+			out:  main + "worktree /src/last\nHEAD 8888888888888888888888888888888888888888\nbranch refs/heads/last",
+			want: []WorktreeInfo{{Path: "/src/last", Head: "8888888888888888888888888888888888888888", Branch: "last"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseWorktreeList(tt.out)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseWorktreeList =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+// Package journal keeps a local, append-only record of every change treework
+// makes to worktrees and branches, with the commits involved, so that
+// removals can be undone.
+package journal
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/vanderhaka/treework/internal/config"
+	"github.com/vanderhaka/treework/internal/fileutil"
+)
+
+// Operations recorded in the journal.
+const (
+	OpCreate       = "create"
+	OpRemove       = "remove"
+	OpBranchDelete = "branch-delete"
+	OpMove         = "move"
+	OpBranchRename = "branch-rename"
+	OpPark         = "park"
+	OpUnpark       = "unpark"
+	OpUndo         = "undo"
+)
+
+// maxEntries is how many entries are kept; older ones are dropped.
+const maxEntries = 2000
+
+// Entry is one recorded operation.
+type Entry struct {
+	ID      string    `json:"id"`
+	Time    time.Time `json:"time"`
+	Op      string    `json:"op"`
+	Repo    string    `json:"repo"`             // Main repo
+	Path    string    `json:"path,omitempty"`   // Worktree folder (new location for moves)
+	From    string    `json:"from,omitempty"`   // Old location (moves) or old branch name (renames)
+	Branch  string    `json:"branch,omitempty"` // Branch checked out, created or deleted
+	SHA     string    `json:"sha,omitempty"`    // Commit the branch or worktree was on
+	Backup  string    `json:"backup,omitempty"` // Backup ID of unsaved work, if one was taken
+	Undoes  string    `json:"undoes,omitempty"` // ID of the entry an undo reversed
+	Command string    `json:"command,omitempty"`
+}
+
+// Path returns the journal file's location.
+func Path() string {
+	return filepath.Join(config.StateDir(), "journal.jsonl")
+}
+
+// Record appends an entry, filling in its ID, time and the command line.
+func Record(e Entry) error {
+	unlock, err := fileutil.Lock(Path())
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	e.Time = time.Now().UTC()
+	e.ID = e.Time.Format("20060102T150405.000000000Z")
+	if e.Command == "" && len(os.Args) > 1 {
+		e.Command = filepath.Base(os.Args[0]) + " " + joinArgs(os.Args[1:])
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(Path(), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return trim()
+}
+
+// Entries returns every entry, oldest first. Unreadable lines are skipped.
+func Entries() []Entry {
+	data, err := os.ReadFile(Path())
+	if err != nil {
+		return nil
+	}
+	var entries []Entry
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var e Entry
+		if json.Unmarshal(scanner.Bytes(), &e) == nil {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// LastUndoable returns the most recent removal that hasn't been undone yet.
+// Folders deleted without knowing their repo can't be brought back, so
+// they're passed over.
+func LastUndoable() (Entry, bool) {
+	entries := Entries()
+	undone := Undone(entries)
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		if e.Op == OpRemove && e.Repo != "" && !undone[e.ID] {
+			return e, true
+		}
+	}
+	return Entry{}, false
+}
+
+// Undone returns the IDs of entries that have been undone.
+func Undone(entries []Entry) map[string]bool {
+	undone := make(map[string]bool)
+	for _, e := range entries {
+		if e.Op == OpUndo {
+			undone[e.Undoes] = true
+		}
+	}
+	return undone
+}
+
+// trim drops the oldest entries once the journal grows past maxEntries.
+// Called with the lock held.
+func trim() error {
+	entries := Entries()
+	if len(entries) <= maxEntries {
+		return nil
+	}
+	var buf bytes.Buffer
+	for _, e := range entries[len(entries)-maxEntries:] {
+		line, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		buf.Write(append(line, '\n'))
+	}
+	return fileutil.WriteAtomic(Path(), buf.Bytes(), 0o644)
+}
+
+// joinArgs joins command-line arguments, quoting those with spaces.
+func joinArgs(args []string) string {
+	var buf bytes.Buffer
+	for i, a := range args {
+		if i > 0 {
+			buf.WriteByte(' ')
+		}
+		if bytes.ContainsAny([]byte(a), " \t\"'") {
+			b, _ := json.Marshal(a)
+			buf.Write(b)
+			continue
+		}
+		buf.WriteString(a)
+	}
+	return buf.String()
+}
@@ -0,0 +1,81 @@
+// Package parallel runs independent pieces of work — mostly git commands —
+// across a bounded pool of goroutines.
+package parallel
+
+import (
+	"context"
+	"runtime"
+	"sync"
+)
+
+// Limit is the default pool size. Git commands spend most of their time
+// waiting on the disk, so a few more than the number of CPUs keeps it busy
+// without starting hundreds of processes at once.
+func Limit() int {
+	return min(max(runtime.NumCPU(), 4)*2, 16)
+}
+
+// ForEach calls fn(i) for i in [0, n) on at most limit goroutines. Once ctx
+// is done no more calls are started; ForEach waits for those already running
+// and returns ctx.Err(). Callers collect results by index, so they come back
+// in order however the calls were scheduled.
+func ForEach(ctx context.Context, limit, n int, fn func(i int)) error {
+	if limit < 1 {
+		limit = 1
+	}
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for i := 0; i < n && ctx.Err() == nil; i++ {
+		select {
+		case <-ctx.Done():
+			continue
+		case sem <- struct{}{}:
+		}
+		if ctx.Err() != nil {
+			<-sem // select picks at random when both are ready
+			break
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer func() { <-sem; wg.Done() }()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+	return ctx.Err()
+}
+
+// Map calls fn on each item on at most limit goroutines and returns the
+// results in the order of items. Items not started before ctx was done are
+// left as the zero value and ctx.Err() is returned.
+func Map[T, R any](ctx context.Context, limit int, items []T, fn func(T) R) ([]R, error) {
+	results := make([]R, len(items))
+	err := ForEach(ctx, limit, len(items), func(i int) {
+		results[i] = fn(items[i])
+	})
+	return results, err
+}
+
+// KeyedMutex serialises work that shares a key, such as git operations that
+// take the same repo's locks, while letting work on other keys run.
+type KeyedMutex struct {
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+// Lock locks key and returns the func that unlocks it.
+func (k *KeyedMutex) Lock(key string) func() {
+	k.mu.Lock()
+	if k.locks == nil {
+		k.locks = make(map[string]*sync.Mutex)
+	}
+	l, ok := k.locks[key]
+	if !ok {
+		l = &sync.Mutex{}
+		k.locks[key] = l
+	}
+	k.mu.Unlock()
+
+	l.Lock()
+	return l.Unlock
+}
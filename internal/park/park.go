@@ -0,0 +1,201 @@
+// Package park records worktrees whose folder was removed to free disk space
+// but whose branch and unsaved work are kept so they can be recreated.
+//
+// Unsaved work is stored as a snapshot commit at refs/treework/park/<repo>/<name>
+// in the repo. Everything else — where the worktree was, what it was based on,
+// its env files — goes in a JSON file under the treework state folder.
+package park
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/vanderhaka/treework/internal/config"
+	"github.com/vanderhaka/treework/internal/fileutil"
+	"github.com/vanderhaka/treework/internal/git"
+)
+
+// RefPrefix is the ref namespace snapshots of parked worktrees live under.
+const RefPrefix = "refs/treework/park/"
+
+// Parked describes a parked worktree.
+type Parked struct {
+	Repo       string            `json:"repo"`                // Repo folder name
+	RepoDir    string            `json:"repo_dir"`            // Main repo
+	Name       string            `json:"name"`                // Worktree folder name
+	Path       string            `json:"path"`                // Where the worktree was, and will be recreated
+	Branch     string            `json:"branch"`              // Branch that was checked out (kept while parked)
+	Head       string            `json:"head"`                // Commit the branch was on when parked
+	BaseBranch string            `json:"base_branch"`         // Branch the work is based on, e.g. main
+	Base       string            `json:"base"`                // Merge base with BaseBranch
+	Snapshot   string            `json:"snapshot"`            // Commit holding uncommitted and untracked files
+	Notes      string            `json:"notes,omitempty"`     // Free text from park --note
+	Ports      map[string]string `json:"ports,omitempty"`     // *PORT variables from the worktree's env files
+	EnvFiles   map[string]string `json:"env_files,omitempty"` // The worktree's own .env* files, restored on unpark
+	ParkedAt   time.Time         `json:"parked_at"`
+}
+
+// Dir returns the folder parked worktree records are kept in.
+func Dir() string {
+	return filepath.Join(config.StateDir(), "parked")
+}
+
+// file returns the path of a parked worktree's record.
+func (p Parked) file() string {
+	return filepath.Join(Dir(), p.Repo, p.Name+".json")
+}
+
+// ref returns the ref holding the parked worktree's snapshot.
+func (p Parked) ref() string {
+	return RefPrefix + p.Repo + "/" + p.Name
+}
+
+// HasChanges reports whether the worktree had uncommitted work when parked.
+func (p Parked) HasChanges() bool {
+	return p.Snapshot != p.Head && !git.SameTree(p.RepoDir, p.Snapshot, p.Head)
+}
+
+// Park records a worktree's branch, unsaved work and env files. The caller
+// removes the folder afterwards; nothing is deleted here.
+func Park(repoDir, wtPath, branch, notes string) (*Parked, error) {
+	if branch == "" || branch == "HEAD" {
+		return nil, fmt.Errorf("worktree is on a detached HEAD — check out a branch before parking it")
+	}
+
+	p := &Parked{
+		Repo:       filepath.Base(repoDir),
+		RepoDir:    repoDir,
+		Name:       filepath.Base(wtPath),
+		Path:       wtPath,
+		Branch:     branch,
+		Head:       git.RevParse(wtPath, "HEAD"),
+		BaseBranch: git.DefaultBranch(repoDir),
+		Notes:      notes,
+		ParkedAt:   time.Now().UTC(),
+	}
+	if _, err := os.Stat(p.file()); err == nil {
+		return nil, fmt.Errorf("%s is already parked", p.Name)
+	}
+	p.Base = git.MergeBase(repoDir, p.Head, p.BaseBranch)
+
+	sha, err := git.Snapshot(wtPath, fmt.Sprintf("treework park of %s\n\nBranch: %s\nPath: %s\n", p.Name, branch, wtPath))
+	if err != nil {
+		return nil, fmt.Errorf("snapshot failed: %w", err)
+	}
+	p.Snapshot = sha
+
+	p.EnvFiles, p.Ports = readEnvFiles(wtPath)
+
+	if err := git.UpdateRef(repoDir, p.ref(), p.Snapshot); err != nil {
+		return nil, err
+	}
+	if err := p.save(); err != nil {
+		git.DeleteRef(repoDir, p.ref())
+		return nil, err
+	}
+	return p, nil
+}
+
+// save writes the record. It can hold secrets from env files, so only the
+// owner can read it.
+func (p Parked) save() error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	return fileutil.WriteAtomic(p.file(), append(data, '\n'), 0o600)
+}
+
+// List returns every parked worktree, oldest first.
+func List() []Parked {
+	files, _ := filepath.Glob(filepath.Join(Dir(), "*", "*.json"))
+	var parked []Parked
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			continue
+		}
+		var p Parked
+		if err := json.Unmarshal(data, &p); err != nil {
+			continue
+		}
+		parked = append(parked, p)
+	}
+	sort.Slice(parked, func(i, j int) bool {
+		return parked[i].ParkedAt.Before(parked[j].ParkedAt)
+	})
+	return parked
+}
+
+// Restore writes the parked work into a worktree recreated at p.Path on
+// p.Branch: uncommitted changes first, then the saved env files. If the
+// branch moved on while parked, the changes are replayed on its new tip.
+func Restore(p Parked) error {
+	if p.HasChanges() {
+		if err := git.ApplyChanges(p.Path, p.Head, p.Snapshot); err != nil {
+			return fmt.Errorf("couldn't reapply uncommitted work (kept at %s): %w", p.ref(), err)
+		}
+	}
+	for name, content := range p.EnvFiles {
+		dst := filepath.Join(p.Path, name)
+		if _, err := os.Stat(dst); err == nil {
+			continue
+		}
+		if err := os.WriteFile(dst, []byte(content), 0o600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Forget deletes the record and snapshot ref of a worktree that was unparked.
+func Forget(p Parked) error {
+	if err := os.Remove(p.file()); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	os.Remove(filepath.Dir(p.file())) // Only succeeds once the repo has none left
+	return git.DeleteRef(p.RepoDir, p.ref())
+}
+
+// readEnvFiles reads a worktree's .env* files and picks out port settings.
+func readEnvFiles(dir string) (files, ports map[string]string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, nil
+	}
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasPrefix(e.Name(), ".env") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			continue
+		}
+		if files == nil {
+			files = make(map[string]string)
+		}
+		files[e.Name()] = string(data)
+
+		scanner := bufio.NewScanner(strings.NewReader(string(data)))
+		for scanner.Scan() {
+			line := strings.TrimPrefix(strings.TrimSpace(scanner.Text()), "export ")
+			key, value, ok := strings.Cut(line, "=")
+			key = strings.TrimSpace(key)
+			if !ok || strings.HasPrefix(key, "#") || !strings.HasSuffix(strings.ToUpper(key), "PORT") {
+				continue
+			}
+			if ports == nil {
+				ports = make(map[string]string)
+			}
+			ports[key] = strings.Trim(strings.TrimSpace(value), `"'`)
+		}
+	}
+	return files, ports
+}
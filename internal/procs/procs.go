@@ -0,0 +1,224 @@
+// Package procs finds processes using a folder — running in it or holding
+// files open inside it — and stops them.
+package procs
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Process is a process that uses a folder.
+type Process struct {
+	PID     int
+	Name    string // Short command name, e.g. "node"
+	Command string // Full command line, if it could be read
+	Reason  string // How it uses the folder, e.g. "working directory" or "has .next/trace open"
+}
+
+// InDir returns the processes (other than this one) whose working directory,
+// executable or open files are inside dir. It reads /proc where there is one
+// and asks lsof elsewhere; if neither is available it returns nothing.
+func InDir(dir string) []Process {
+	dir = realPath(dir)
+	var found []Process
+	if _, err := os.Stat("/proc/self/cwd"); err == nil {
+		found = fromProc(dir)
+	} else {
+		found = fromLsof(dir)
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].PID < found[j].PID })
+	return found
+}
+
+// fromProc scans /proc. Processes belonging to other users can't be
+// inspected and are skipped.
+func fromProc(dir string) []Process {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil
+	}
+	self := os.Getpid()
+
+	var found []Process
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil || pid == self {
+			continue
+		}
+		base := filepath.Join("/proc", e.Name())
+		reason := ""
+		if cwd, err := os.Readlink(filepath.Join(base, "cwd")); err == nil && within(dir, cwd) {
+			reason = "working directory"
+		} else if exe, err := os.Readlink(filepath.Join(base, "exe")); err == nil && within(dir, exe) {
+			reason = "running " + rel(dir, exe)
+		} else if fds, err := os.ReadDir(filepath.Join(base, "fd")); err == nil {
+			for _, fd := range fds {
+				if target, err := os.Readlink(filepath.Join(base, "fd", fd.Name())); err == nil && within(dir, target) {
+					reason = "has " + rel(dir, target) + " open"
+					break
+				}
+			}
+		}
+		if reason == "" {
+			continue
+		}
+
+		p := Process{PID: pid, Reason: reason}
+		if comm, err := os.ReadFile(filepath.Join(base, "comm")); err == nil {
+			p.Name = strings.TrimSpace(string(comm))
+		}
+		if cmdline, err := os.ReadFile(filepath.Join(base, "cmdline")); err == nil {
+			p.Command = strings.TrimSpace(string(bytes.ReplaceAll(cmdline, []byte{0}, []byte{' '})))
+		}
+		found = append(found, p)
+	}
+	return found
+}
+
+// fromLsof lists every open file with lsof and keeps those inside dir. This
+// is quicker than lsof +D, which walks the whole folder (node_modules and all).
+func fromLsof(dir string) []Process {
+	out, err := exec.Command("lsof", "-n", "-P", "-w", "-F", "pcfn").Output()
+	if len(out) == 0 && err != nil {
+		return nil
+	}
+	self := os.Getpid()
+
+	byPID := make(map[int]*Process)
+	var order []int
+	var pid int
+	var name, fd string
+	for _, line := range strings.Split(string(out), "\n") {
+		if line == "" {
+			continue
+		}
+		value := line[1:]
+		switch line[0] {
+		case 'p':
+			pid, _ = strconv.Atoi(value)
+		case 'c':
+			name = value
+		case 'f':
+			fd = value
+		case 'n':
+			if pid == self || !within(dir, value) || byPID[pid] != nil {
+				continue
+			}
+			p := &Process{PID: pid, Name: name, Reason: "has " + rel(dir, value) + " open"}
+			switch fd {
+			case "cwd":
+				p.Reason = "working directory"
+			case "txt":
+				p.Reason = "running " + rel(dir, value)
+			}
+			byPID[pid] = p
+			order = append(order, pid)
+		}
+	}
+
+	var found []Process
+	for _, pid := range order {
+		p := byPID[pid]
+		if out, err := exec.Command("ps", "-o", "command=", "-p", strconv.Itoa(pid)).Output(); err == nil {
+			p.Command = strings.TrimSpace(string(out))
+		}
+		found = append(found, *p)
+	}
+	return found
+}
+
+// Terminate asks each process to exit with SIGTERM, waits up to timeout,
+// then sends SIGKILL to any that are left. Returns the processes that were
+// still running afterwards.
+func Terminate(procs []Process, timeout time.Duration) []Process {
+	for _, p := range procs {
+		signal(p.PID, syscall.SIGTERM)
+	}
+	remaining := waitForExit(procs, timeout)
+	if len(remaining) == 0 {
+		return nil
+	}
+	for _, p := range remaining {
+		signal(p.PID, syscall.SIGKILL)
+	}
+	return waitForExit(remaining, time.Second)
+}
+
+// waitForExit polls until every process has exited or timeout passes, and
+// returns those still running.
+func waitForExit(procs []Process, timeout time.Duration) []Process {
+	deadline := time.Now().Add(timeout)
+	for {
+		var running []Process
+		for _, p := range procs {
+			if alive(p.PID) {
+				running = append(running, p)
+			}
+		}
+		if len(running) == 0 || time.Now().After(deadline) {
+			return running
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+func signal(pid int, sig os.Signal) error {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	return p.Signal(sig)
+}
+
+// alive reports whether a process exists, using signal 0. Zombies still
+// count until their parent reaps them, so they are checked for in /proc.
+func alive(pid int) bool {
+	if signal(pid, syscall.Signal(0)) != nil {
+		return false
+	}
+	stat, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return true
+	}
+	// Fields after the command name in parentheses start with the state
+	if i := bytes.LastIndexByte(stat, ')'); i >= 0 && i+2 < len(stat) {
+		return stat[i+2] != 'Z'
+	}
+	return true
+}
+
+// Contains reports whether path is dir or inside it, resolving symlinks.
+func Contains(dir, path string) bool {
+	return within(realPath(dir), realPath(path))
+}
+
+func within(dir, path string) bool {
+	return path == dir || strings.HasPrefix(path, dir+string(filepath.Separator))
+}
+
+// rel returns path relative to dir for display.
+func rel(dir, path string) string {
+	if r, err := filepath.Rel(dir, path); err == nil {
+		return r
+	}
+	return path
+}
+
+// realPath makes a path absolute and resolves symlinks (e.g. /tmp → /private/tmp
+// on macOS), since the kernel reports resolved paths.
+func realPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	return filepath.Clean(path)
+}
@@ -0,0 +1,305 @@
+// Package repoindex finds the git repos under each base folder and caches
+// them, with the modification time of every folder it looked in, so later
+// scans only re-read the folders that have changed.
+package repoindex
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vanderhaka/treework/internal/config"
+	"github.com/vanderhaka/treework/internal/fileutil"
+	"github.com/vanderhaka/treework/internal/git"
+)
+
+// version is bumped when the file format or what a scan finds changes, so
+// old indexes are rebuilt rather than misread.
+const version = 2
+
+// What kind of git folder a folder is.
+const (
+	kindRepo     = "repo"     // Has a .git folder, or a .git file pointing to one kept elsewhere
+	kindBare     = "bare"     // A bare repo
+	kindWorktree = "worktree" // A linked worktree: its .git file points into a repo's worktrees folder
+)
+
+// dirEntry is what a scan saw in one folder. It records every child folder,
+// whatever the settings, so changing them doesn't mean reading folders again.
+type dirEntry struct {
+	MTime   int64    `json:"mtime"`             // Folder's modification time, in nanoseconds
+	Kind    string   `json:"kind,omitempty"`    // kindRepo, kindBare, kindWorktree or "" for a plain folder
+	Subdirs []string `json:"subdirs,omitempty"` // Child folders, other than .git
+}
+
+// settings are the scan settings an index was built with.
+type settings struct {
+	Depth  int      `json:"depth"`
+	Ignore []string `json:"ignore"`
+	Nested bool     `json:"nested"`
+}
+
+func (s settings) equal(o settings) bool {
+	return s.Depth == o.Depth && s.Nested == o.Nested && slices.Equal(s.Ignore, o.Ignore)
+}
+
+func currentSettings(root string) settings {
+	depth, ignore, nested := config.ScanFor(root)
+	return settings{Depth: depth, Ignore: ignore, Nested: nested}
+}
+
+// rootIndex is the index of one base folder.
+type rootIndex struct {
+	Scanned  time.Time           `json:"scanned"`
+	Settings settings            `json:"settings"`
+	Repos    []string            `json:"repos"`
+	Dirs     map[string]dirEntry `json:"dirs"`
+}
+
+type indexFile struct {
+	Version int                   `json:"version"`
+	Roots   map[string]*rootIndex `json:"roots"`
+}
+
+// Path returns the index file's location.
+func Path() string {
+	return filepath.Join(config.CacheDir(), "repos.json")
+}
+
+// refreshing holds the roots with a background refresh under way.
+var refreshing sync.Map
+
+// Repos returns the repos under root. If root has been indexed, the cached
+// list is returned at once, less any repos that have since been deleted,
+// and the index is brought up to date in the background for next time (if
+// the program is still running when it finishes). Otherwise, or if the scan
+// settings have changed since, root is scanned now.
+func Repos(root string) []string {
+	root = filepath.Clean(root)
+	idx := load().Roots[root]
+	if idx == nil || !idx.Settings.equal(currentSettings(root)) {
+		return Refresh(root)
+	}
+
+	if _, running := refreshing.LoadOrStore(root, true); !running {
+		go Refresh(root)
+	}
+
+	var repos []string
+	for _, r := range idx.Repos {
+		if isGitDir(r) {
+			repos = append(repos, r)
+		}
+	}
+	return repos
+}
+
+// Cached returns the repos recorded in root's index, less any that have since
+// been deleted, without scanning or refreshing anything. It returns nil if
+// root hasn't been indexed.
+func Cached(root string) []string {
+	idx := load().Roots[filepath.Clean(root)]
+	if idx == nil {
+		return nil
+	}
+	var repos []string
+	for _, r := range idx.Repos {
+		if isGitDir(r) {
+			repos = append(repos, r)
+		}
+	}
+	return repos
+}
+
+// Refresh brings root's index up to date, re-reading only folders whose
+// modification time has changed, and returns its repos.
+func Refresh(root string) []string {
+	root = filepath.Clean(root)
+	return scan(root, load().Roots[root])
+}
+
+// Rescan scans root from scratch, ignoring what's indexed, and returns its repos.
+func Rescan(root string) []string {
+	return scan(filepath.Clean(root), nil)
+}
+
+// Scanned returns when root was last scanned, or the zero time if it never was.
+func Scanned(root string) time.Time {
+	if idx := load().Roots[filepath.Clean(root)]; idx != nil {
+		return idx.Scanned
+	}
+	return time.Time{}
+}
+
+// racyWindow covers file systems with coarse timestamps: a folder modified
+// this close to the previous scan may have changed again since without its
+// modification time moving, so it's read again.
+const racyWindow = 2 * time.Second
+
+// scan walks root, reusing prev's record of any folder that hasn't changed,
+// and saves the result.
+func scan(root string, prev *rootIndex) []string {
+	s := scanner{
+		root: root,
+		idx:  &rootIndex{Scanned: time.Now(), Settings: currentSettings(root), Dirs: make(map[string]dirEntry)},
+	}
+	if prev != nil {
+		s.prev = prev.Dirs
+		s.trustBefore = prev.Scanned.Add(-racyWindow).UnixNano()
+	}
+	s.walk(root, 0)
+	save(root, s.idx)
+	return s.idx.Repos
+}
+
+type scanner struct {
+	root        string
+	idx         *rootIndex
+	prev        map[string]dirEntry
+	trustBefore int64 // Previous entries for folders modified after this are re-read
+}
+
+// walk records dir and looks for repos in and below it. A folder whose
+// modification time hasn't changed still has the same children, so its
+// previous entry is used instead of reading it again. Bare repos and
+// worktrees aren't looked inside, nor are repos unless nested scanning is
+// on.
+func (s *scanner) walk(dir string, depth int) {
+	info, err := os.Stat(dir)
+	if err != nil || !info.IsDir() {
+		return
+	}
+
+	mtime := info.ModTime().UnixNano()
+	e, ok := s.prev[dir]
+	if !ok || e.MTime != mtime || mtime >= s.trustBefore {
+		e = readDir(dir, mtime)
+	}
+	s.idx.Dirs[dir] = e
+
+	switch e.Kind {
+	case kindWorktree:
+		return
+	case kindBare:
+		s.idx.Repos = append(s.idx.Repos, dir)
+		return
+	case kindRepo:
+		s.idx.Repos = append(s.idx.Repos, dir)
+		if !s.idx.Settings.Nested {
+			return
+		}
+	}
+	if depth >= s.idx.Settings.Depth {
+		return
+	}
+	for _, name := range e.Subdirs {
+		child := filepath.Join(dir, name)
+		if !s.ignored(child) {
+			s.walk(child, depth+1)
+		}
+	}
+}
+
+// ignored reports whether dir matches a scan_ignore pattern. Patterns with
+// a / are matched against the path below the base folder, others against
+// the folder's name.
+func (s *scanner) ignored(dir string) bool {
+	rel, err := filepath.Rel(s.root, dir)
+	if err != nil {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+	for _, p := range s.idx.Settings.Ignore {
+		name := path.Base(rel)
+		if strings.Contains(p, "/") {
+			name = rel
+		}
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// readDir lists the folder, noting what kind of git folder it is, if any,
+// and its children.
+func readDir(dir string, mtime int64) dirEntry {
+	e := dirEntry{MTime: mtime}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return e
+	}
+	var head, objects, refs bool
+	for _, entry := range entries {
+		name := entry.Name()
+		switch {
+		case name == ".git" && entry.IsDir():
+			e.Kind = kindRepo
+		case name == ".git":
+			// A gitdir file: a linked worktree if it points into a repo's
+			// worktrees folder, otherwise a repo whose git folder is kept
+			// elsewhere (a submodule or --separate-git-dir clone)
+			switch {
+			case git.IsLinkedWorktree(dir):
+				e.Kind = kindWorktree
+			case git.LinkedGitDir(dir) != "":
+				e.Kind = kindRepo
+			}
+		case name == "HEAD" && !entry.IsDir():
+			head = true
+		case entry.IsDir():
+			objects = objects || name == "objects"
+			refs = refs || name == "refs"
+			e.Subdirs = append(e.Subdirs, name)
+		}
+	}
+	if e.Kind == "" && head && objects && refs {
+		e.Kind = kindBare
+	}
+	return e
+}
+
+// isGitDir reports whether dir still looks like a repo: it has a .git
+// folder or file, or is a bare repo.
+func isGitDir(dir string) bool {
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+		return true
+	}
+	_, err := os.Stat(filepath.Join(dir, "HEAD"))
+	return err == nil
+}
+
+func load() indexFile {
+	var f indexFile
+	data, err := os.ReadFile(Path())
+	if err == nil {
+		json.Unmarshal(data, &f)
+	}
+	if f.Version != version || f.Roots == nil {
+		return indexFile{Version: version, Roots: make(map[string]*rootIndex)}
+	}
+	return f
+}
+
+// save stores root's index, keeping other roots' as they are on disk. The
+// index is only a cache, so failures are ignored.
+func save(root string, idx *rootIndex) {
+	unlock, err := fileutil.Lock(Path())
+	if err != nil {
+		return
+	}
+	defer unlock()
+
+	f := load()
+	f.Roots[root] = idx
+	data, err := json.Marshal(f)
+	if err != nil {
+		return
+	}
+	fileutil.WriteAtomic(Path(), data, 0o644)
+}
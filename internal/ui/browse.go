@@ -0,0 +1,684 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/atotto/clipboard"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/dustin/go-humanize"
+	"github.com/muesli/termenv"
+	"github.com/vanderhaka/treework/internal/parallel"
+)
+
+// WorktreeState is what the worktree browser shows about a worktree once
+// it's been checked.
+type WorktreeState struct {
+	Dirty    int       // Uncommitted files
+	Unpushed int       // Commits not pushed
+	Behind   int       // Upstream commits not pulled
+	Merged   bool      // Branch is merged into the default branch
+	Activity time.Time // Last commit, or last change to an uncommitted file
+	Age      string    // Activity for display, e.g. "3 hours ago"
+}
+
+// Sort orders for BrowseWorktrees.
+const (
+	SortRepo   = "repo"   // By repo, then name
+	SortRecent = "recent" // Most recently active first
+	SortName   = "name"   // By worktree name
+	SortStatus = "status" // Unsaved work first, merged last
+)
+
+// SortOrders lists the sort orders in the order tab cycles through them.
+var SortOrders = []string{SortRepo, SortRecent, SortName, SortStatus}
+
+// currentLabel marks the worktree the user is in.
+var currentLabel = SuccessStyle.Render("  ● you are here")
+
+// Actions BrowseWorktrees returns along with the chosen worktree.
+const (
+	ActionSelect = "select" // Enter: whatever the command does
+	ActionOpen   = "open"   // ctrl+o: open it in the editor
+	ActionRemove = "remove" // ctrl+d: remove it
+)
+
+// WorktreePreview is what the preview pane shows about a worktree.
+type WorktreePreview struct {
+	Branch   string
+	Upstream string
+	Ahead    int
+	Behind   int
+	Status   []string // git status --short lines
+	Commits  []string // Latest commits, newest first
+	EnvFiles []string
+}
+
+// Browser configures BrowseWorktrees.
+type Browser struct {
+	Title   string                                       // e.g. "Remove a worktree"; the scope is added to it
+	Scope   string                                       // Repo the list is limited to, if any: ctrl+a returns AllValue
+	Sort    string                                       // One of SortOrders
+	Select  string                                       // What enter does, for the help line, e.g. "remove"
+	Check   func(i int) WorktreeState                    // Run for every item in the background
+	Preview func(path string) WorktreePreview            // Run for the highlighted item
+	Size    func(ctx context.Context, path string) int64 // Disk usage of the highlighted item, or -1
+}
+
+// BrowseWorktrees shows worktrees full screen, grouped by repo, with a
+// preview of the highlighted one, and returns the chosen one's path and
+// what to do with it. The list appears at once; b.Check is run for each item
+// on a bounded pool of goroutines and badges fill in as results arrive, and
+// the preview loads as items are highlighted. Type to filter, tab to sort,
+// ctrl+y to copy the highlighted path. Esc returns huh.ErrUserAborted.
+func BrowseWorktrees(items []WorktreeDisplay, b Browser) (path, action string, err error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	results := make(chan stateMsg)
+	go func() {
+		defer close(results)
+		parallel.ForEach(ctx, parallel.Limit(), len(items), func(i int) {
+			st := b.Check(i)
+			select {
+			case results <- stateMsg{i: i, state: st}:
+			case <-ctx.Done():
+			}
+		})
+	}()
+
+	input := textinput.New()
+	input.Prompt = "/ "
+	input.Placeholder = "type to filter"
+	input.PromptStyle = InfoStyle
+	input.Focus()
+
+	m := &browseModel{
+		b:        b,
+		ctx:      ctx,
+		items:    items,
+		states:   make([]*WorktreeState, len(items)),
+		sortBy:   b.Sort,
+		input:    input,
+		results:  results,
+		previews: make(map[string]*WorktreePreview),
+		sizes:    make(map[string]int64),
+	}
+	m.layout()
+	if _, err := tea.NewProgram(m, tea.WithAltScreen()).Run(); err != nil {
+		return "", "", err
+	}
+	if m.aborted {
+		return "", "", huh.ErrUserAborted
+	}
+	return m.chosen, m.action, nil
+}
+
+type stateMsg struct {
+	i     int
+	state WorktreeState
+}
+
+type previewMsg struct {
+	path    string
+	preview WorktreePreview
+}
+
+type sizeMsg struct {
+	path string
+	size int64
+	walk int // Which measurement this is; see browseModel.walk
+}
+
+// browseLine is a line of the list: an item, or a repo heading if item is -1.
+type browseLine struct {
+	item  int
+	group string
+}
+
+type browseModel struct {
+	b       Browser
+	ctx     context.Context
+	items   []WorktreeDisplay
+	states  []*WorktreeState // nil until checked
+	checked int
+	sortBy  string
+	input   textinput.Model
+	results chan stateMsg
+
+	previews map[string]*WorktreePreview // nil while loading
+	sizes    map[string]int64            // -2 while measuring, -1 if unknown
+
+	// Only the highlighted worktree is measured; moving on cancels it
+	walk       int // Counts measurements started
+	walkPath   string
+	cancelWalk context.CancelFunc
+
+	lines  []browseLine
+	cursor int // Index into lines; always an item
+	offset int // First line shown
+	width  int
+	height int
+	flash  string // Shown in place of the help line until the next key
+
+	chosen  string
+	action  string
+	aborted bool
+	done    bool
+}
+
+func (m *browseModel) Init() tea.Cmd {
+	return tea.Batch(textinput.Blink, m.waitForState(), m.loadPreview())
+}
+
+// loadPreview starts loading the highlighted item's preview and size, if
+// they haven't been already.
+func (m *browseModel) loadPreview() tea.Cmd {
+	it := m.current()
+	if it < 0 {
+		return nil
+	}
+	path := m.items[it].Path
+	var cmds []tea.Cmd
+	if _, ok := m.previews[path]; !ok && m.b.Preview != nil {
+		m.previews[path] = nil
+		cmds = append(cmds, func() tea.Msg {
+			return previewMsg{path: path, preview: m.b.Preview(path)}
+		})
+	}
+	if m.walkPath != path && m.cancelWalk != nil {
+		m.cancelWalk()
+		m.cancelWalk = nil
+		if m.sizes[m.walkPath] == -2 {
+			delete(m.sizes, m.walkPath) // Measure again if it's highlighted again
+		}
+		m.walkPath = ""
+	}
+	if _, ok := m.sizes[path]; !ok && m.b.Size != nil {
+		m.sizes[path] = -2 // Measuring
+		m.walk++
+		ctx, cancel := context.WithCancel(m.ctx)
+		m.walkPath, m.cancelWalk = path, cancel
+		walk := m.walk
+		cmds = append(cmds, func() tea.Msg {
+			return sizeMsg{path: path, size: m.b.Size(ctx, path), walk: walk}
+		})
+	}
+	return tea.Batch(cmds...)
+}
+
+// waitForState delivers the next check result, if there are more to come.
+func (m *browseModel) waitForState() tea.Cmd {
+	return func() tea.Msg {
+		if msg, ok := <-m.results; ok {
+			return msg
+		}
+		return nil
+	}
+}
+
+func (m *browseModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case stateMsg:
+		st := msg.state
+		m.states[msg.i] = &st
+		m.checked++
+		m.layout()
+		return m, tea.Batch(m.waitForState(), m.loadPreview())
+	case previewMsg:
+		p := msg.preview
+		m.previews[msg.path] = &p
+		return m, nil
+	case sizeMsg:
+		if msg.walk == m.walk && m.cancelWalk != nil {
+			m.cancelWalk()
+			m.walkPath, m.cancelWalk = "", nil
+		} else if msg.size < 0 {
+			return m, nil // Cancelled
+		}
+		m.sizes[msg.path] = msg.size
+		return m, nil
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.scroll()
+		return m, nil
+	case tea.KeyMsg:
+		m.flash = ""
+		switch msg.String() {
+		case "ctrl+c":
+			m.aborted, m.done = true, true
+			return m, tea.Quit
+		case "esc":
+			if m.input.Value() != "" {
+				m.input.SetValue("")
+				m.layout()
+				return m, nil
+			}
+			m.aborted, m.done = true, true
+			return m, tea.Quit
+		case "enter", "ctrl+o", "ctrl+d":
+			if it := m.current(); it >= 0 {
+				m.chosen, m.done = m.items[it].Path, true
+				m.action = map[string]string{"enter": ActionSelect, "ctrl+o": ActionOpen, "ctrl+d": ActionRemove}[msg.String()]
+				return m, tea.Quit
+			}
+			return m, nil
+		case "ctrl+y":
+			if it := m.current(); it >= 0 {
+				copyText(m.items[it].Path)
+				m.flash = SuccessStyle.Render("✓ ") + "Copied " + m.items[it].Path
+			}
+			return m, nil
+		case "ctrl+a":
+			if m.b.Scope != "" {
+				m.chosen, m.done = AllValue, true
+				return m, tea.Quit
+			}
+			return m, nil
+		case "tab", "shift+tab":
+			step := 1
+			if msg.String() == "shift+tab" {
+				step = len(SortOrders) - 1
+			}
+			i := slices.Index(SortOrders, m.sortBy)
+			m.sortBy = SortOrders[(i+step)%len(SortOrders)]
+			m.layout()
+			return m, nil
+		case "up", "ctrl+p", "ctrl+k":
+			m.move(-1)
+			return m, m.loadPreview()
+		case "down", "ctrl+n", "ctrl+j":
+			m.move(1)
+			return m, m.loadPreview()
+		case "pgup":
+			m.move(-m.visible())
+			return m, m.loadPreview()
+		case "pgdown":
+			m.move(m.visible())
+			return m, m.loadPreview()
+		}
+	}
+
+	before := m.input.Value()
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	if m.input.Value() != before {
+		m.layout()
+		m.cursor = m.next(0, 1)
+		m.scroll()
+		cmd = tea.Batch(cmd, m.loadPreview())
+	}
+	return m, cmd
+}
+
+// copyText puts text on the clipboard, falling back to the terminal's
+// clipboard (OSC 52, which also works over ssh) when there's no clipboard
+// tool to use.
+func copyText(text string) {
+	if err := clipboard.WriteAll(text); err != nil {
+		termenv.Copy(text)
+	}
+}
+
+// current returns the item under the cursor, or -1 if nothing matches.
+func (m *browseModel) current() int {
+	if m.cursor < 0 || m.cursor >= len(m.lines) {
+		return -1
+	}
+	return m.lines[m.cursor].item
+}
+
+// next returns the first item line at or after from, stepping by dir, or
+// the nearest one the other way if there is none.
+func (m *browseModel) next(from, dir int) int {
+	for i := from; i >= 0 && i < len(m.lines); i += dir {
+		if m.lines[i].item >= 0 {
+			return i
+		}
+	}
+	for i := from; i >= 0 && i < len(m.lines); i -= dir {
+		if m.lines[i].item >= 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+// move moves the cursor by n items.
+func (m *browseModel) move(n int) {
+	if m.cursor < 0 {
+		return
+	}
+	dir := 1
+	if n < 0 {
+		dir, n = -1, -n
+	}
+	for ; n > 0; n-- {
+		i := m.cursor + dir
+		for i >= 0 && i < len(m.lines) && m.lines[i].item < 0 {
+			i += dir
+		}
+		if i < 0 || i >= len(m.lines) {
+			break
+		}
+		m.cursor = i
+	}
+	m.scroll()
+}
+
+// visible is how many lines of the list fit on screen, under the title and
+// filter and above the help line.
+func (m *browseModel) visible() int {
+	if m.height == 0 {
+		return 15
+	}
+	return max(m.height-4, 3)
+}
+
+// scroll keeps the cursor, and the heading above it, on screen.
+func (m *browseModel) scroll() {
+	if m.cursor < 0 {
+		m.offset = 0
+		return
+	}
+	top := m.cursor
+	if top > 0 && m.lines[top-1].item < 0 {
+		top--
+	}
+	if top < m.offset {
+		m.offset = top
+	}
+	if m.cursor >= m.offset+m.visible() {
+		m.offset = m.cursor - m.visible() + 1
+	}
+}
+
+// layout filters and sorts the items into lines, grouped by repo, keeping
+// the cursor on the same item.
+func (m *browseModel) layout() {
+	keep := m.current()
+
+	query := strings.TrimSpace(m.input.Value())
+	var order []int
+	for i, it := range m.items {
+		if query == "" || m.matches(query, it) {
+			order = append(order, i)
+		}
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return m.less(order[a], order[b])
+	})
+
+	// Groups appear in the order of their first item
+	var groups []string
+	members := make(map[string][]int)
+	for _, i := range order {
+		repo := m.items[i].Repo
+		if _, ok := members[repo]; !ok {
+			groups = append(groups, repo)
+		}
+		members[repo] = append(members[repo], i)
+	}
+
+	m.lines = m.lines[:0]
+	m.cursor = -1
+	for _, g := range groups {
+		m.lines = append(m.lines, browseLine{item: -1, group: g})
+		for _, i := range members[g] {
+			if i == keep {
+				m.cursor = len(m.lines)
+			}
+			m.lines = append(m.lines, browseLine{item: i})
+		}
+	}
+	if m.cursor < 0 {
+		m.cursor = m.next(0, 1)
+	}
+	m.scroll()
+}
+
+func (m *browseModel) matches(query string, it WorktreeDisplay) bool {
+	for _, key := range []string{it.Name(), it.Branch, it.Repo} {
+		if _, ok := fuzzyScore(query, key); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// less orders items a and b by the current sort order.
+func (m *browseModel) less(a, b int) bool {
+	ia, ib := m.items[a], m.items[b]
+	sa, sb := m.states[a], m.states[b]
+	switch m.sortBy {
+	case SortRecent:
+		ta, tb := activity(sa), activity(sb)
+		if !ta.Equal(tb) {
+			return ta.After(tb)
+		}
+	case SortStatus:
+		if ra, rb := attention(sa), attention(sb); ra != rb {
+			return ra < rb
+		}
+	case SortRepo:
+		if ia.Repo != ib.Repo {
+			return ia.Repo < ib.Repo
+		}
+	}
+	return ia.Name() < ib.Name()
+}
+
+func activity(st *WorktreeState) time.Time {
+	if st == nil {
+		return time.Time{}
+	}
+	return st.Activity
+}
+
+// attention ranks how much a worktree needs looking at: unsaved work
+// first, then behind its upstream, then clean, then merged, then unchecked.
+func attention(st *WorktreeState) int {
+	switch {
+	case st == nil:
+		return 4
+	case st.Dirty > 0 || st.Unpushed > 0:
+		return 0
+	case st.Behind > 0:
+		return 1
+	case st.Merged:
+		return 3
+	}
+	return 2
+}
+
+// previewWidth is the narrowest terminal the preview pane is shown in.
+const previewWidth = 90
+
+func (m *browseModel) View() string {
+	if m.done {
+		return ""
+	}
+	var b strings.Builder
+	title := m.b.Title
+	if m.b.Scope != "" {
+		title += " in " + m.b.Scope
+	}
+	status := "sort: " + m.sortBy
+	if m.checked < len(m.items) {
+		status += fmt.Sprintf(" · checking %d/%d", m.checked, len(m.items))
+	}
+	b.WriteString("  " + BoldStyle.Render(title) + "  " + MutedStyle.Render(status) + "\n")
+	b.WriteString("  " + m.input.View() + "\n")
+
+	list := m.listView()
+	if m.width >= previewWidth {
+		listWidth := m.width * 11 / 20
+		height := m.visible()
+		// Cut long rows off rather than wrapping them, then pad to the column
+		list = lipgloss.NewStyle().MaxWidth(listWidth).Render(list)
+		left := lipgloss.NewStyle().Width(listWidth).Height(height).MaxHeight(height).Render(list)
+		right := lipgloss.NewStyle().
+			BorderStyle(lipgloss.NormalBorder()).BorderLeft(true).BorderForeground(Gray).
+			PaddingLeft(1).Width(m.width - listWidth - 2).MaxWidth(m.width - listWidth).
+			Height(height).MaxHeight(height).
+			Render(m.previewView())
+		b.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, left, right) + "\n")
+	} else {
+		b.WriteString(list)
+	}
+
+	if m.flash != "" {
+		b.WriteString("  " + m.flash + "\n")
+		return b.String()
+	}
+	selectHelp := m.b.Select
+	if selectHelp == "" {
+		selectHelp = "select"
+	}
+	help := "↑↓ move • enter " + selectHelp
+	if selectHelp != "open" {
+		help += " • ctrl+o open"
+	}
+	if selectHelp != "remove" {
+		help += " • ctrl+d remove"
+	}
+	help += " • ctrl+y copy path • tab sort • esc back"
+	if m.b.Scope != "" {
+		help += " • ctrl+a all repos"
+	}
+	b.WriteString(MutedStyle.Render("  "+help) + "\n")
+	return b.String()
+}
+
+// listView renders the visible part of the list.
+func (m *browseModel) listView() string {
+	var b strings.Builder
+	nameWidth, branchWidth := 0, 0
+	for _, it := range m.items {
+		nameWidth = max(nameWidth, min(len(it.Name()), 40))
+		branchWidth = max(branchWidth, min(len(it.Branch), 30))
+	}
+
+	if len(m.lines) == 0 {
+		b.WriteString(MutedStyle.Render("    No matches") + "\n")
+	}
+	end := min(m.offset+m.visible(), len(m.lines))
+	for i := m.offset; i < end; i++ {
+		line := m.lines[i]
+		if line.item < 0 {
+			b.WriteString("  " + BoldStyle.Render(line.group) + "\n")
+			continue
+		}
+		cursor := "  "
+		if i == m.cursor {
+			cursor = InfoStyle.Render("> ")
+		}
+		it := m.items[line.item]
+		row := fmt.Sprintf("%-*s  %s", nameWidth, it.Name(), MutedStyle.Render(fmt.Sprintf("%-*s", branchWidth, it.Branch)))
+		if badges := m.badges(line.item); badges != "" {
+			row += "  " + badges
+		}
+		b.WriteString("  " + cursor + row + "\n")
+	}
+	return b.String()
+}
+
+// previewView renders the preview pane for the highlighted item.
+func (m *browseModel) previewView() string {
+	it := m.current()
+	if it < 0 {
+		return ""
+	}
+	path := m.items[it].Path
+	var b strings.Builder
+	b.WriteString(BoldStyle.Render(filepath.Base(path)) + "\n")
+	b.WriteString(MutedStyle.Render(path) + "\n")
+
+	size := "measuring…"
+	switch n, ok := m.sizes[path]; {
+	case !ok || n == -2:
+	case n < 0:
+		size = "unknown"
+	default:
+		size = humanize.Bytes(uint64(n))
+	}
+	b.WriteString(MutedStyle.Render("Size ") + size + "\n")
+
+	p := m.previews[path]
+	if p == nil {
+		b.WriteString("\n" + MutedStyle.Render("Loading…") + "\n")
+		return b.String()
+	}
+
+	branch := p.Branch
+	if branch == "" {
+		branch = "detached HEAD"
+	}
+	if p.Upstream != "" {
+		branch += MutedStyle.Render(" → "+p.Upstream) + fmt.Sprintf("  ↑%d ↓%d", p.Ahead, p.Behind)
+	} else {
+		branch += MutedStyle.Render("  no upstream")
+	}
+	b.WriteString(MutedStyle.Render("Branch ") + branch + "\n")
+
+	section := func(heading string, lines []string, empty string) {
+		b.WriteString("\n" + InfoStyle.Render(heading) + "\n")
+		if len(lines) == 0 {
+			b.WriteString(MutedStyle.Render(empty) + "\n")
+		}
+		for _, l := range lines {
+			b.WriteString(l + "\n")
+		}
+	}
+	status := p.Status
+	if len(status) > 10 {
+		status = append(status[:10:10], MutedStyle.Render(fmt.Sprintf("… %d more", len(p.Status)-10)))
+	}
+	section("Changes", status, "clean")
+	section("Recent commits", p.Commits, "none")
+	section("Env files", p.EnvFiles, "none")
+	return b.String()
+}
+
+// badges renders what's known about item i: its lock and, once checked,
+// unsaved work, how it stands against its upstream, whether it's merged
+// and when it was last worked in.
+func (m *browseModel) badges(i int) string {
+	it := m.items[i]
+	var parts []string
+	if it.Locked {
+		parts = append(parts, WarnStyle.Render("locked"))
+	}
+	if st := m.states[i]; st == nil {
+		parts = append(parts, MutedStyle.Render("…"))
+	} else {
+		if st.Dirty > 0 {
+			parts = append(parts, WarnStyle.Render(fmt.Sprintf("%d dirty", st.Dirty)))
+		}
+		if st.Unpushed > 0 {
+			parts = append(parts, WarnStyle.Render(fmt.Sprintf("↑%d", st.Unpushed)))
+		}
+		if st.Behind > 0 {
+			parts = append(parts, InfoStyle.Render(fmt.Sprintf("↓%d", st.Behind)))
+		}
+		if st.Merged {
+			parts = append(parts, SuccessStyle.Render("merged"))
+		}
+		if st.Age != "" {
+			parts = append(parts, MutedStyle.Render(st.Age))
+		}
+	}
+	s := strings.Join(parts, " ")
+	if it.Current {
+		s += currentLabel
+	}
+	return s
+}
@@ -0,0 +1,276 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/huh"
+)
+
+// RepoChoice is a repo offered by SelectRepo.
+type RepoChoice struct {
+	Path   string
+	Name   string // Folder name
+	Parent string // Folder it's in, for telling apart repos with the same name, e.g. "~/work"
+	Alias  string
+	Pinned bool
+}
+
+// SelectRepo prompts the user to pick a repo, listed in the order given.
+// Typing filters the list fuzzily by name, alias and parent folder, best
+// match first.
+func SelectRepo(repos []RepoChoice) (string, error) {
+	items := make([]pickItem, len(repos))
+	for i, r := range repos {
+		label := r.Name
+		if r.Pinned {
+			label = WarnStyle.Render("★ ") + label
+		}
+		if r.Alias != "" {
+			label += InfoStyle.Render("  " + r.Alias)
+		}
+		label += MutedStyle.Render("  " + r.Parent)
+
+		keys := []string{r.Name, r.Alias, r.Parent + "/" + r.Name}
+		items[i] = pickItem{value: r.Path, label: label, keys: keys}
+	}
+	return pick("Select a project", items)
+}
+
+// pickItem is one row of a fuzzy picker.
+type pickItem struct {
+	value string
+	label string
+	keys  []string // Matched against the filter; earlier keys count for more
+}
+
+// pick shows items under a filter input and returns the chosen item's
+// value, or huh.ErrUserAborted if the user backs out.
+func pick(title string, items []pickItem) (string, error) {
+	input := textinput.New()
+	input.Prompt = "/ "
+	input.Placeholder = "type to filter"
+	input.PromptStyle = InfoStyle
+	input.Focus()
+
+	m := &pickModel{title: title, items: items, input: input}
+	m.filter()
+	if _, err := tea.NewProgram(m).Run(); err != nil {
+		return "", err
+	}
+	if m.aborted {
+		return "", huh.ErrUserAborted
+	}
+	return m.chosen, nil
+}
+
+type pickModel struct {
+	title   string
+	items   []pickItem
+	input   textinput.Model
+	matches []int // Indexes into items, best first
+	cursor  int
+	offset  int // First match shown
+	height  int
+
+	chosen  string
+	aborted bool
+	done    bool
+}
+
+func (m *pickModel) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (m *pickModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.height = msg.Height
+		return m, nil
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c":
+			m.aborted, m.done = true, true
+			return m, tea.Quit
+		case "esc":
+			if m.input.Value() != "" {
+				m.input.SetValue("")
+				m.filter()
+				return m, nil
+			}
+			m.aborted, m.done = true, true
+			return m, tea.Quit
+		case "enter":
+			if len(m.matches) == 0 {
+				return m, nil
+			}
+			m.chosen, m.done = m.items[m.matches[m.cursor]].value, true
+			return m, tea.Quit
+		case "up", "ctrl+p", "ctrl+k":
+			m.move(-1)
+			return m, nil
+		case "down", "ctrl+n", "ctrl+j":
+			m.move(1)
+			return m, nil
+		case "pgup":
+			m.move(-m.rows())
+			return m, nil
+		case "pgdown":
+			m.move(m.rows())
+			return m, nil
+		}
+	}
+
+	before := m.input.Value()
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	if m.input.Value() != before {
+		m.filter()
+	}
+	return m, cmd
+}
+
+// move moves the cursor by n rows, scrolling to keep it in view.
+func (m *pickModel) move(n int) {
+	if len(m.matches) == 0 {
+		return
+	}
+	m.cursor = min(max(m.cursor+n, 0), len(m.matches)-1)
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+m.rows() {
+		m.offset = m.cursor - m.rows() + 1
+	}
+}
+
+// rows is how many matches fit on screen.
+func (m *pickModel) rows() int {
+	if m.height == 0 {
+		return 10
+	}
+	return max(m.height-6, 3)
+}
+
+// filter recomputes the matches for the current input.
+func (m *pickModel) filter() {
+	query := strings.TrimSpace(m.input.Value())
+	m.matches = m.matches[:0]
+	m.cursor, m.offset = 0, 0
+	if query == "" {
+		for i := range m.items {
+			m.matches = append(m.matches, i)
+		}
+		return
+	}
+
+	scores := make(map[int]int)
+	for i, it := range m.items {
+		best, found := 0, false
+		for k, key := range it.keys {
+			if s, ok := fuzzyScore(query, key); ok {
+				s += (len(it.keys) - k) * 10
+				if !found || s > best {
+					best, found = s, true
+				}
+			}
+		}
+		if found {
+			scores[i] = best
+			m.matches = append(m.matches, i)
+		}
+	}
+	sort.SliceStable(m.matches, func(a, b int) bool {
+		return scores[m.matches[a]] > scores[m.matches[b]]
+	})
+}
+
+func (m *pickModel) View() string {
+	if m.done {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("  " + BoldStyle.Render(m.title) + "\n")
+	b.WriteString("  " + m.input.View() + "\n")
+
+	if len(m.matches) == 0 {
+		b.WriteString(MutedStyle.Render("    No matches") + "\n")
+	}
+	end := min(m.offset+m.rows(), len(m.matches))
+	for i := m.offset; i < end; i++ {
+		cursor := "  "
+		if i == m.cursor {
+			cursor = InfoStyle.Render("> ")
+		}
+		b.WriteString("  " + cursor + m.items[m.matches[i]].label + "\n")
+	}
+	if len(m.matches) > end-m.offset {
+		b.WriteString(MutedStyle.Render(fmt.Sprintf("    %d/%d", m.cursor+1, len(m.matches))) + "\n")
+	}
+	b.WriteString(MutedStyle.Render("  ↑ up • ↓ down • enter select • esc back") + "\n")
+	return b.String()
+}
+
+// fuzzyScore reports whether every character of query appears in text in
+// order, ignoring case, and scores the best way of matching them:
+// consecutive characters and ones at the start of a word score more, gaps
+// score less.
+func fuzzyScore(query, text string) (int, bool) {
+	q := []rune(strings.ToLower(query))
+	t := []rune(strings.ToLower(text))
+	if len(q) == 0 || len(q) > len(t) {
+		return 0, false
+	}
+
+	// best[j] is the best score with the query so far matched and its last
+	// character at t[j], or none if that isn't possible
+	const none = -1 << 30
+	best := make([]int, len(t))
+	next := make([]int, len(t))
+	for i, c := range q {
+		for j := range t {
+			next[j] = none
+			if t[j] != c {
+				continue
+			}
+			bonus := 0
+			switch {
+			case j == 0:
+				bonus = 8
+			case !unicode.IsLetter(t[j-1]) && !unicode.IsDigit(t[j-1]):
+				bonus = 6 // Start of a word: after - _ . / or a space
+			}
+			if i == 0 {
+				next[j] = bonus
+				continue
+			}
+			for k := 0; k < j; k++ {
+				if best[k] == none {
+					continue
+				}
+				s := best[k] + bonus - min(j-k-1, 3)
+				if k == j-1 {
+					s += 5
+				}
+				next[j] = max(next[j], s)
+			}
+		}
+		best, next = next, best
+	}
+
+	score := none
+	for _, s := range best {
+		score = max(score, s)
+	}
+	if score == none {
+		return 0, false
+	}
+	if len(q) == len(t) {
+		score += 10 // Exact match
+	}
+	return score, true
+}
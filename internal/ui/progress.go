@@ -0,0 +1,285 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/term"
+)
+
+// ItemState is how far an item in a batch operation has got.
+type ItemState int
+
+const (
+	ItemWaiting ItemState = iota
+	ItemChecking
+	ItemRemoving
+	ItemDeletingBranch
+	ItemDone
+	ItemFailed
+	ItemSkipped // Never started, e.g. because the operation was cancelled
+)
+
+func (s ItemState) String() string {
+	switch s {
+	case ItemChecking:
+		return "checking"
+	case ItemRemoving:
+		return "removing"
+	case ItemDeletingBranch:
+		return "deleting branch"
+	case ItemDone:
+		return "done"
+	case ItemFailed:
+		return "failed"
+	case ItemSkipped:
+		return "skipped"
+	}
+	return "waiting"
+}
+
+func (s ItemState) finished() bool {
+	return s == ItemDone || s == ItemFailed || s == ItemSkipped
+}
+
+// ProgressItem is one row of a Progress view.
+type ProgressItem struct {
+	Name   string
+	Group  string // Shown as a heading above the first item of each group, e.g. the repo
+	State  ItemState
+	Detail string // Failure reason, or what happened, e.g. "deleted branch 'x'"
+}
+
+// Progress shows the state of each worktree in a batch removal while it
+// runs, then a summary. On a terminal it redraws in place; otherwise each
+// change is printed as a line. Update may be called from any goroutine.
+type Progress struct {
+	title string
+
+	mu    sync.Mutex
+	items []ProgressItem
+	plain bool // Not a terminal: print lines instead of redrawing
+}
+
+// NewProgress creates a progress view for items, all waiting.
+func NewProgress(title string, items []ProgressItem) *Progress {
+	return &Progress{
+		title: title,
+		items: items,
+		plain: !term.IsTerminal(os.Stdout.Fd()),
+	}
+}
+
+// Update sets the state of item i and what to show next to it.
+func (p *Progress) Update(i int, state ItemState, detail string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.items[i].State = state
+	p.items[i].Detail = detail
+	if p.plain {
+		it := p.items[i]
+		if it.Group != "" {
+			it.Name = it.Group + "/" + it.Name
+		}
+		fmt.Println(p.line(it, "…"))
+	}
+}
+
+// Items returns a copy of the items as they stand.
+func (p *Progress) Items() []ProgressItem {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]ProgressItem(nil), p.items...)
+}
+
+// Run calls work and shows progress until it returns, then prints the
+// summary. Ctrl+C cancels the context work is given; work should stop
+// starting items and return once the running ones finish. Items still
+// waiting then are marked skipped. Returns whether it was cancelled.
+func (p *Progress) Run(work func(ctx context.Context)) bool {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		work(ctx)
+	}()
+
+	if p.plain {
+		fmt.Println(InfoStyle.Render("  ℹ ") + p.title)
+		<-done
+	} else {
+		s := spinner.New()
+		s.Spinner = spinner.Dot
+		s.Style = InfoStyle
+		m := &progressModel{p: p, spinner: s, cancel: cancel}
+		program := tea.NewProgram(m, tea.WithoutSignalHandler())
+		go func() {
+			<-done
+			program.Send(workDoneMsg{})
+		}()
+		if _, err := program.Run(); err != nil {
+			<-done
+		}
+	}
+
+	p.mu.Lock()
+	for i := range p.items {
+		if p.items[i].State == ItemWaiting {
+			p.items[i].State = ItemSkipped
+			p.items[i].Detail = "cancelled"
+		}
+	}
+	p.mu.Unlock()
+	p.summary()
+	return ctx.Err() != nil
+}
+
+// line renders one item; spin stands in for items that are under way.
+func (p *Progress) line(it ProgressItem, spin string) string {
+	var icon string
+	switch it.State {
+	case ItemDone:
+		icon = SuccessStyle.Render("✓")
+	case ItemFailed:
+		icon = ErrorStyle.Render("✗")
+	case ItemWaiting, ItemSkipped:
+		icon = MutedStyle.Render("·")
+	default:
+		icon = spin
+	}
+	text := it.State.String()
+	if it.Detail != "" {
+		text += " — " + it.Detail
+	}
+	if it.State == ItemFailed {
+		text = ErrorStyle.Render(text)
+	} else {
+		text = MutedStyle.Render(text)
+	}
+	return fmt.Sprintf("  %s %s  %s", icon, it.Name, text)
+}
+
+// summary prints a table of how each item ended up, grouped, and the totals.
+func (p *Progress) summary() {
+	width := 0
+	for _, it := range p.items {
+		width = max(width, len(it.Name))
+	}
+
+	fmt.Println()
+	group := ""
+	counts := make(map[ItemState]int)
+	for _, it := range p.items {
+		if it.Group != "" && it.Group != group {
+			group = it.Group
+			fmt.Println("  " + BoldStyle.Render(group))
+		}
+		counts[it.State]++
+		result := it.State.String()
+		switch it.State {
+		case ItemDone:
+			result = SuccessStyle.Render(fmt.Sprintf("%-7s", "removed"))
+		case ItemFailed:
+			result = ErrorStyle.Render(fmt.Sprintf("%-7s", result))
+		default:
+			result = MutedStyle.Render(fmt.Sprintf("%-7s", result))
+		}
+		fmt.Printf("    %-*s  %s  %s\n", width, it.Name, result, MutedStyle.Render(it.Detail))
+	}
+
+	parts := []string{fmt.Sprintf("%d removed", counts[ItemDone])}
+	if n := counts[ItemFailed]; n > 0 {
+		parts = append(parts, fmt.Sprintf("%d failed", n))
+	}
+	if n := counts[ItemSkipped]; n > 0 {
+		parts = append(parts, fmt.Sprintf("%d skipped", n))
+	}
+	fmt.Println()
+	Muted(strings.Join(parts, ", "))
+}
+
+type workDoneMsg struct{}
+
+// progressModel redraws a Progress on every spinner tick.
+type progressModel struct {
+	p          *Progress
+	spinner    spinner.Model
+	cancel     context.CancelFunc
+	cancelling bool
+	done       bool
+	height     int
+}
+
+func (m *progressModel) Init() tea.Cmd {
+	return m.spinner.Tick
+}
+
+func (m *progressModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case workDoneMsg:
+		m.done = true // The summary follows, so leave nothing behind
+		return m, tea.Quit
+	case tea.WindowSizeMsg:
+		m.height = msg.Height
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" {
+			m.cancelling = true
+			m.cancel()
+		}
+	}
+	var cmd tea.Cmd
+	m.spinner, cmd = m.spinner.Update(msg)
+	return m, cmd
+}
+
+func (m *progressModel) View() string {
+	if m.done {
+		return ""
+	}
+	items := m.p.Items()
+
+	counts := make(map[ItemState]int)
+	for _, it := range items {
+		counts[it.State]++
+	}
+	finished := counts[ItemDone] + counts[ItemFailed] + counts[ItemSkipped]
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("  %s %s %s\n", m.spinner.View(), m.p.title,
+		MutedStyle.Render(fmt.Sprintf("%d/%d", finished, len(items)))))
+
+	// Show every item if they fit; otherwise just the ones under way
+	fits := m.height == 0 || len(items)+4 <= m.height
+	hidden := 0
+	group := ""
+	for _, it := range items {
+		if !fits && (it.State == ItemWaiting || it.State.finished()) {
+			hidden++
+			continue
+		}
+		if it.Group != "" && it.Group != group {
+			group = it.Group
+			b.WriteString("  " + BoldStyle.Render(group) + "\n")
+		}
+		b.WriteString(m.p.line(it, m.spinner.View()) + "\n")
+	}
+	if hidden > 0 {
+		b.WriteString(MutedStyle.Render(fmt.Sprintf("    %d done, %d failed, %d waiting",
+			counts[ItemDone], counts[ItemFailed], counts[ItemWaiting])) + "\n")
+	}
+
+	if m.cancelling {
+		b.WriteString(WarnStyle.Render("  Cancelling — waiting for running git commands to finish...") + "\n")
+	} else {
+		b.WriteString(MutedStyle.Render("  ctrl+c cancel") + "\n")
+	}
+	return b.String()
+}
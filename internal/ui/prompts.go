@@ -14,6 +14,10 @@ import (
 // BackValue is the sentinel value returned when the user picks "← Back".
 const BackValue = "__back__"
 
+// AllValue is the sentinel value returned when the user asks to see the
+// worktrees of every repo instead of just the current one.
+const AllValue = "__all__"
+
 // keymap returns a custom huh keymap with Escape and left arrow mapped to quit (back).
 func keymap() *huh.KeyMap {
 	km := huh.NewDefaultKeyMap()
@@ -54,24 +58,6 @@ func runFieldDefault(field huh.Field) error {
 	return huh.NewForm(huh.NewGroup(field)).WithKeyMap(defaultKeymap()).Run()
 }
 
-// SelectRepo prompts the user to pick a repo from a list.
-func SelectRepo(repos []string) (string, error) {
-	var opts []huh.Option[string]
-	for _, r := range repos {
-		label := filepath.Base(r)
-		opts = append(opts, huh.NewOption(label, r))
-	}
-
-	var selected string
-	field := huh.NewSelect[string]().
-		Title("Select a project").
-		Options(opts...).
-		Value(&selected)
-
-	err := runField(field)
-	return selected, err
-}
-
 // InputName prompts the user to enter a worktree name.
 func InputName() (string, error) {
 	var name string
@@ -86,9 +72,17 @@ func InputName() (string, error) {
 
 // WorktreeDisplay holds display info for a worktree in the selector.
 type WorktreeDisplay struct {
-	Path   string
-	Branch string
-	Repo   string
+	Path       string
+	Branch     string
+	Repo       string
+	Locked     bool
+	LockReason string
+	Current    bool // The user's shell is inside it
+}
+
+// Name returns the worktree's folder name.
+func (w WorktreeDisplay) Name() string {
+	return filepath.Base(w.Path)
 }
 
 // SelectWorktree prompts the user to pick a worktree from a list.
@@ -126,6 +120,13 @@ func SelectWorktreeDetailed(items []WorktreeDisplay) (string, error) {
 		if item.Repo != "" {
 			label += MutedStyle.Render("  " + item.Repo)
 		}
+		if item.Locked {
+			lock := "  locked"
+			if item.LockReason != "" {
+				lock += ": " + item.LockReason
+			}
+			label += WarnStyle.Render(lock)
+		}
 		opts = append(opts, huh.NewOption(label, item.Path))
 	}
 
@@ -139,6 +140,48 @@ func SelectWorktreeDetailed(items []WorktreeDisplay) (string, error) {
 	return selected, err
 }
 
+// RemovalChoice is a worktree offered by SelectWorktreesToRemove.
+type RemovalChoice struct {
+	Path    string
+	Branch  string
+	Note    string // e.g. "squash-merged into main" or "2 modified, 1 unpushed commit(s)"
+	Unsaved bool   // Note describes work that removal would lose
+	Checked bool   // Selected to start with
+}
+
+// SelectWorktreesToRemove lets the user tick which worktrees to remove.
+// Returns the chosen paths.
+func SelectWorktreesToRemove(items []RemovalChoice) ([]string, error) {
+	var opts []huh.Option[string]
+	for _, item := range items {
+		label := filepath.Base(item.Path)
+		if item.Branch != "" {
+			label += MutedStyle.Render("  (" + item.Branch + ")")
+		}
+		if item.Unsaved {
+			label += WarnStyle.Render("  unsaved: " + item.Note)
+		} else if item.Note != "" {
+			label += MutedStyle.Render("  " + item.Note)
+		}
+		opts = append(opts, huh.NewOption(label, item.Path).Selected(item.Checked))
+	}
+
+	var selected []string
+	field := huh.NewMultiSelect[string]().
+		Title("Select worktrees to remove").
+		Options(opts...).
+		Value(&selected)
+
+	err := runField(field)
+	return selected, err
+}
+
+// ConfirmRemove prompts whether to remove the worktree picked with a
+// shortcut from a list that isn't for removing.
+func ConfirmRemove(name string) (bool, error) {
+	return Confirm(fmt.Sprintf("Remove %s?", name))
+}
+
 // ConfirmOpen prompts whether to open the selected worktree in the editor.
 func ConfirmOpen(name string) (bool, error) {
 	return Confirm(fmt.Sprintf("Open %s in editor?", name))
@@ -153,7 +196,7 @@ func SelectAction() (string, error) {
 			huh.NewOption("Create new worktree", "new"),
 			huh.NewOption("List worktrees", "ls"),
 			huh.NewOption("Remove a worktree", "rm"),
-			huh.NewOption("Remove ALL worktrees for a repo", "clear"),
+			huh.NewOption("Remove several worktrees for a repo", "clear"),
 			huh.NewOption(MutedStyle.Render("Settings"), "settings"),
 			huh.NewOption(MutedStyle.Render("Quit"), "quit"),
 		).
@@ -187,19 +230,71 @@ func ConfirmForceDelete(branch string) (bool, error) {
 	return Confirm(fmt.Sprintf("Force delete unmerged branch '%s'?", branch))
 }
 
-// WarnDirtyWorktree prints user-friendly warnings about unsaved work.
-func WarnDirtyWorktree(hasUncommitted, hasUnpushed bool) {
+// DirtyDetails is the unsaved work in a worktree, as shown by WarnDirtyWorktree.
+type DirtyDetails struct {
+	Changes    string   // Counts, e.g. "2 modified, 1 untracked"
+	Files      []string // Changed paths with their git status letters
+	Commits    []string // Commits that exist only here, "sha subject"
+	CommitsOn  string   // Where they're missing from, e.g. "origin/feature"
+	Detached   bool     // Commits are on a detached HEAD, not on any branch
+	Operation  string   // Merge, rebase, etc. in progress
+	Submodules []string // Submodules with their own changes
+	Stashes    []string // Stash entries made on the branch
+}
+
+// maxListed is how many files or commits WarnDirtyWorktree lists before
+// summarising the rest.
+const maxListed = 10
+
+// WarnDirtyWorktree prints user-friendly warnings about unsaved work,
+// listing the files and commits at risk.
+func WarnDirtyWorktree(d DirtyDetails) {
 	fmt.Println()
 	Warn("This worktree has unsaved work!")
 	fmt.Println()
-	if hasUncommitted {
-		Muted("  • You have uncommitted changes (files you edited but didn't save with git)")
+	if d.Changes != "" {
+		Muted(fmt.Sprintf("  • Uncommitted changes (%s) — files you edited but didn't save with git:", d.Changes))
+		listItems(d.Files)
+	}
+	if len(d.Commits) > 0 {
+		switch {
+		case d.Detached:
+			Muted("  • Commits on a detached HEAD that no branch or tag points to:")
+		case d.CommitsOn != "":
+			Muted(fmt.Sprintf("  • Commits not pushed to %s (saved locally but not backed up):", d.CommitsOn))
+		default:
+			Muted("  • Commits that haven't been pushed (saved locally but not backed up):")
+		}
+		listItems(d.Commits)
+	}
+	if d.Operation != "" {
+		Muted(fmt.Sprintf("  • A %s is in progress and hasn't been finished", d.Operation))
 	}
-	if hasUnpushed {
-		Muted("  • You have commits that haven't been pushed (saved locally but not backed up)")
+	if len(d.Submodules) > 0 {
+		Muted("  • Submodules with their own unsaved changes:")
+		listItems(d.Submodules)
 	}
 	fmt.Println()
 	Muted("  Removing it will permanently delete this work.")
+	if len(d.Stashes) > 0 {
+		noun := "entries"
+		if len(d.Stashes) == 1 {
+			noun = "entry"
+		}
+		Muted(fmt.Sprintf("  %d stash %s made on this branch will be kept:", len(d.Stashes), noun))
+		listItems(d.Stashes)
+	}
+}
+
+// listItems prints up to maxListed indented items, then a count of the rest.
+func listItems(items []string) {
+	for i, item := range items {
+		if i == maxListed {
+			Muted(fmt.Sprintf("      … and %d more", len(items)-maxListed))
+			return
+		}
+		Muted("      " + item)
+	}
 }
 
 // ConfirmDirtyRemove asks the user to confirm removal of a dirty worktree.
@@ -242,7 +337,7 @@ func SelectSettingsAction() (string, error) {
 	field := huh.NewSelect[string]().
 		Title("Settings").
 		Options(
-			huh.NewOption("Change base folder", "base_dir"),
+			huh.NewOption("Base folders", "roots"),
 			huh.NewOption("Change editor", "editor"),
 			huh.NewOption(MutedStyle.Render("← Back"), BackValue),
 		).
@@ -252,6 +347,46 @@ func SelectSettingsAction() (string, error) {
 	return action, err
 }
 
+// SelectRootsAction prompts the user to add or remove a base folder.
+func SelectRootsAction(canRemove bool) (string, error) {
+	opts := []huh.Option[string]{
+		huh.NewOption("Add a folder", "add"),
+	}
+	if canRemove {
+		opts = append(opts, huh.NewOption("Remove a folder", "remove"))
+	}
+	opts = append(opts, huh.NewOption(MutedStyle.Render("← Back"), BackValue))
+
+	var action string
+	field := huh.NewSelect[string]().
+		Title("Base folders").
+		Options(opts...).
+		Value(&action)
+
+	err := runField(field)
+	return action, err
+}
+
+// SelectRoot prompts the user to pick one of the configured base folders.
+// Returns BackValue if the user picks "← Back".
+func SelectRoot(roots []string) (string, error) {
+	opts := []huh.Option[string]{
+		huh.NewOption(MutedStyle.Render("← Back"), BackValue),
+	}
+	for _, r := range roots {
+		opts = append(opts, huh.NewOption(r, r))
+	}
+
+	var selected string
+	field := huh.NewSelect[string]().
+		Title("Remove which folder?").
+		Options(opts...).
+		Value(&selected)
+
+	err := runField(field)
+	return selected, err
+}
+
 // SelectPathMethod prompts the user to choose how to set the base folder path.
 func SelectPathMethod() (string, error) {
 	var method string